@@ -18,6 +18,8 @@ package v1alpha1
 
 import (
 	"github.com/kyma-project/module-manager/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -35,12 +37,251 @@ type BtpOperator struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec   BtpOperatorSpec `json:"spec,omitempty"`
-	Status types.Status    `json:"status,omitempty"`
+	Spec   BtpOperatorSpec   `json:"spec,omitempty"`
+	Status BtpOperatorStatus `json:"status,omitempty"`
 }
 
 // BtpOperatorSpec defines the desired state of BtpOperator
-type BtpOperatorSpec struct{}
+type BtpOperatorSpec struct {
+	// CredentialsSecretRef points to the Secret with Service Manager credentials for sap-btp-operator.
+	// If not set, the Secret configured for btp-manager (by default sap-btp-manager in kyma-system) is used.
+	// +optional
+	CredentialsSecretRef *CredentialsSecretRef `json:"credentialsSecretRef,omitempty"`
+
+	// Deployment contains settings applied to the sap-btp-operator Deployment
+	// +optional
+	Deployment *DeploymentSettings `json:"deployment,omitempty"`
+
+	// ChartValues are Helm values merged over the module chart overrides when the sap-btp-operator chart is rendered.
+	// Values derived from the credentials Secret take precedence.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	ChartValues *apiextensionsv1.JSON `json:"chartValues,omitempty"`
+
+	// DeletionPolicy defines what happens with Service Instances and Service Bindings when the module is removed
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// DeletionProtection blocks deprovisioning while Service Instances or Service Bindings exist in the cluster,
+	// unless the deletion is confirmed with the DeletionConfirmedAnnotation
+	// +optional
+	DeletionProtection bool `json:"deletionProtection,omitempty"`
+
+	// NamespaceCredentials bind credentials of other subaccounts to namespaces. Service Instances and Service Bindings
+	// in the listed namespaces are managed with these credentials instead of the cluster-wide ones.
+	// +optional
+	NamespaceCredentials []NamespaceCredentials `json:"namespaceCredentials,omitempty"`
+
+	// UpgradeRollback defines whether the module resources of the last successfully applied chart version are applied again
+	// when the module resources of a new chart version do not become ready
+	// +kubebuilder:default=Enabled
+	// +optional
+	UpgradeRollback UpgradeRollback `json:"upgradeRollback,omitempty"`
+}
+
+// NamespaceCredentials binds credentials from a Secret to namespaces
+type NamespaceCredentials struct {
+	// Namespaces which use the credentials
+	// +kubebuilder:validation:MinItems=1
+	Namespaces []string `json:"namespaces"`
+
+	// SecretRef points to the Secret with the credentials
+	SecretRef CredentialsSecretRef `json:"secretRef"`
+}
+
+// DeletionConfirmedAnnotation set to "true" on the BtpOperator CR confirms deletion blocked by DeletionProtection
+const DeletionConfirmedAnnotation = "operator.kyma-project.io/deletion-confirmed"
+
+// DeprovisioningPreviewAnnotation set to "true" on the BtpOperator CR requests a report of resources which would be
+// deleted by the deprovisioning, without deleting anything
+const DeprovisioningPreviewAnnotation = "operator.kyma-project.io/deprovisioning-preview"
+
+// RestoreServiceResourcesAnnotation set to "true" on the BtpOperator CR in Ready state requests recreation of
+// Service Instances and Service Bindings backed up before the soft delete
+const RestoreServiceResourcesAnnotation = "operator.kyma-project.io/restore-service-resources"
+
+// DeletionPolicy defines how Service Instances and Service Bindings are handled during deprovisioning
+// +kubebuilder:validation:Enum=Delete;SoftDeleteOnly;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes Service Instances and Service Bindings in SAP BTP and falls back to soft delete
+	// if the deletion fails or times out
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+
+	// DeletionPolicySoftDeleteOnly removes finalizers from Service Instances and Service Bindings and deletes them
+	// from the cluster only, so the instances in SAP BTP are kept
+	DeletionPolicySoftDeleteOnly DeletionPolicy = "SoftDeleteOnly"
+
+	// DeletionPolicyOrphan removes SAP BTP Service Operator but leaves Service Instances, Service Bindings
+	// and their CRDs untouched
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// UpgradeRollback defines the behavior when the module resources of a new chart version do not become ready
+// +kubebuilder:validation:Enum=Enabled;Disabled
+type UpgradeRollback string
+
+const (
+	// UpgradeRollbackEnabled applies the module resources of the last successfully applied chart version again
+	UpgradeRollbackEnabled UpgradeRollback = "Enabled"
+
+	// UpgradeRollbackDisabled leaves the module resources of the new chart version in the cluster
+	UpgradeRollbackDisabled UpgradeRollback = "Disabled"
+)
+
+// CredentialsSecretRef references a Secret with Service Manager credentials
+type CredentialsSecretRef struct {
+	// Name of the Secret
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// Namespace of the Secret. Defaults to the namespace btp-manager installs the module to.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// KeyMapping maps the expected credentials keys (clientid, clientsecret, sm_url, tokenurl, cluster_id, tls.crt, tls.key, certurl)
+	// to the keys used in the referenced Secret. Keys not listed here are read as they are.
+	// +optional
+	KeyMapping map[string]string `json:"keyMapping,omitempty"`
+}
+
+// BtpOperatorStatus defines the observed state of BtpOperator
+type BtpOperatorStatus struct {
+	types.Status `json:",inline"`
+
+	// Resources lists the module resources applied by btp-manager during the last reconciliation
+	// +optional
+	Resources []ResourceStatus `json:"resources,omitempty"`
+
+	// Deprovisioning reports the progress of the deprovisioning
+	// +optional
+	Deprovisioning *DeprovisioningStatus `json:"deprovisioning,omitempty"`
+
+	// Credentials reports the credentials applied to sap-btp-operator
+	// +optional
+	Credentials *CredentialsStatus `json:"credentials,omitempty"`
+}
+
+// CredentialsStatus describes the credentials applied to sap-btp-operator
+type CredentialsStatus struct {
+	// Hash is the content hash of the sap-btp-operator Secret and ConfigMap data stamped on the Deployment Pod template
+	// +optional
+	Hash string `json:"hash,omitempty"`
+
+	// RotationTime is the time when a change of the credentials was last rolled out to the Deployment
+	// +optional
+	RotationTime *metav1.Time `json:"rotationTime,omitempty"`
+}
+
+// DeprovisioningPhase is the last step reached by the deprovisioning
+// +kubebuilder:validation:Enum=HardDeleteStarted;SoftDeleteStarted;ResourcesRemoved
+type DeprovisioningPhase string
+
+const (
+	// DeprovisioningHardDeleteStarted means that deletion of Service Instances and Service Bindings was requested
+	DeprovisioningHardDeleteStarted DeprovisioningPhase = "HardDeleteStarted"
+
+	// DeprovisioningSoftDeleteStarted means that finalizers of Service Instances and Service Bindings are being removed
+	DeprovisioningSoftDeleteStarted DeprovisioningPhase = "SoftDeleteStarted"
+
+	// DeprovisioningResourcesRemoved means that the module resources were removed
+	DeprovisioningResourcesRemoved DeprovisioningPhase = "ResourcesRemoved"
+)
+
+// DeprovisioningStatus reports the progress of removing Service Instances and Service Bindings
+type DeprovisioningStatus struct {
+	// Phase is the last step reached by the deprovisioning, the deprovisioning continues from it after a restart
+	// +optional
+	Phase DeprovisioningPhase `json:"phase,omitempty"`
+
+	// StartTime is the time when the hard delete started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// SoftDeleteDeadline is the time when the deprovisioning falls back to soft delete if the hard delete does not finish
+	// +optional
+	SoftDeleteDeadline *metav1.Time `json:"softDeleteDeadline,omitempty"`
+
+	// SoftDeleteStartTime is the time when the soft delete started
+	// +optional
+	SoftDeleteStartTime *metav1.Time `json:"softDeleteStartTime,omitempty"`
+
+	// Elapsed is the time elapsed since the hard delete started, as of the last progress update
+	// +optional
+	Elapsed string `json:"elapsed,omitempty"`
+
+	// Remaining lists the number of Service Instances and Service Bindings left per namespace
+	// +optional
+	Remaining []RemainingServiceResources `json:"remaining,omitempty"`
+
+	// Stuck lists Service Instances and Service Bindings which report a failure
+	// +optional
+	Stuck []StuckServiceResource `json:"stuck,omitempty"`
+}
+
+// RemainingServiceResources is the number of Service Instances and Service Bindings left in a namespace
+type RemainingServiceResources struct {
+	// Namespace of the Service Instances and Service Bindings
+	Namespace string `json:"namespace"`
+
+	// ServiceInstances is the number of Service Instances left in the namespace
+	ServiceInstances int `json:"serviceInstances"`
+
+	// ServiceBindings is the number of Service Bindings left in the namespace
+	ServiceBindings int `json:"serviceBindings"`
+}
+
+// StuckServiceResource is a Service Instance or Service Binding which reports a failure
+type StuckServiceResource struct {
+	// Kind of the resource, ServiceInstance or ServiceBinding
+	Kind string `json:"kind"`
+
+	// Namespace of the resource
+	Namespace string `json:"namespace"`
+
+	// Name of the resource
+	Name string `json:"name"`
+
+	// Reason of the failure condition reported by the resource
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message of the failure condition reported by the resource
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ResourceStatus describes a module resource applied by btp-manager
+type ResourceStatus struct {
+	// Group of the resource, empty for the core API group
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Kind of the resource
+	Kind string `json:"kind"`
+
+	// Namespace of the resource, empty for cluster-scoped resources
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the resource
+	Name string `json:"name"`
+
+	// ChartVersion is the version of the module chart the resource was applied from
+	// +optional
+	ChartVersion string `json:"chartVersion,omitempty"`
+
+	// Ready tells if the resource passed the readiness check
+	Ready bool `json:"ready"`
+
+	// LastError is the last error which occurred while applying the resource or checking its readiness
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
 
 var _ types.CustomObject = &BtpOperator{}
 
@@ -49,11 +290,11 @@ func (o *BtpOperator) ComponentName() string {
 }
 
 func (o *BtpOperator) GetStatus() types.Status {
-	return o.Status
+	return o.Status.Status
 }
 
 func (o *BtpOperator) SetStatus(status types.Status) {
-	o.Status = status
+	o.Status.Status = status
 }
 
 func (o *BtpOperator) IsReasonStringEqual(reason string) bool {
@@ -64,6 +305,37 @@ func (o *BtpOperator) IsReasonStringEqual(reason string) bool {
 	return condition != nil && condition.Reason == reason
 }
 
+// DeploymentSettings defines overrides for the sap-btp-operator Deployment
+type DeploymentSettings struct {
+	// Replicas is the number of sap-btp-operator pods. Leader election is enabled when more than one replica is requested.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources of the sap-btp-operator manager container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector of the sap-btp-operator pods
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the sap-btp-operator pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the sap-btp-operator pods
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName of the sap-btp-operator pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
 //+kubebuilder:object:root=true
 
 // BtpOperatorList contains a list of BtpOperator
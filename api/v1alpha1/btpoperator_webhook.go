@@ -0,0 +1,217 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// CredentialsKeys are the keys supported in the credentials Secret, either clientsecret and tokenurl
+// or tls.crt, tls.key and certurl are expected together with the other keys
+var CredentialsKeys = []string{"clientid", "clientsecret", "sm_url", "tokenurl", "cluster_id", "tls.crt", "tls.key", "certurl"}
+
+var btpoperatorlog = logf.Log.WithName("btpoperator-resource")
+
+// BtpOperatorValidator validates BtpOperator CRs on admission
+type BtpOperatorValidator struct {
+	client.Reader
+}
+
+func (r *BtpOperator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&BtpOperatorValidator{Reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-operator-kyma-project-io-v1alpha1-btpoperator,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.kyma-project.io,resources=btpoperators,verbs=create;update,versions=v1alpha1,name=vbtpoperator.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &BtpOperatorValidator{}
+
+// ValidateCreate rejects a BtpOperator CR if another one already exists in the cluster or if its spec is invalid
+func (v *BtpOperatorValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	cr, err := toBtpOperator(obj)
+	if err != nil {
+		return err
+	}
+	btpoperatorlog.Info("validate create", "name", cr.Name, "namespace", cr.Namespace)
+
+	existing := &BtpOperatorList{}
+	if err := v.List(ctx, existing); err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("while listing existing BtpOperator CRs: %w", err))
+	}
+	for _, item := range existing.Items {
+		if item.Namespace == cr.Namespace && item.Name == cr.Name {
+			continue
+		}
+		return apierrors.NewForbidden(
+			schema.GroupResource{Group: GroupVersion.Group, Resource: "btpoperators"},
+			cr.Name,
+			fmt.Errorf("'%s' BtpOperator CR in '%s' namespace already exists, only one BtpOperator CR is allowed in the cluster",
+				item.Name, item.Namespace),
+		)
+	}
+
+	return cr.validateSpec()
+}
+
+// ValidateUpdate rejects an update of a BtpOperator CR with an invalid spec
+func (v *BtpOperatorValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	cr, err := toBtpOperator(newObj)
+	if err != nil {
+		return err
+	}
+	btpoperatorlog.Info("validate update", "name", cr.Name, "namespace", cr.Namespace)
+
+	return cr.validateSpec()
+}
+
+// ValidateDelete allows every deletion
+func (v *BtpOperatorValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func toBtpOperator(obj runtime.Object) (*BtpOperator, error) {
+	cr, ok := obj.(*BtpOperator)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a BtpOperator but got %T", obj))
+	}
+	return cr, nil
+}
+
+func (r *BtpOperator) validateSpec() error {
+	specPath := field.NewPath("spec")
+	errs := field.ErrorList{}
+	errs = append(errs, validateCredentialsSecretRef(r.Spec.CredentialsSecretRef, specPath.Child("credentialsSecretRef"))...)
+	errs = append(errs, validateDeploymentSettings(r.Spec.Deployment, specPath.Child("deployment"))...)
+	errs = append(errs, validateNamespaceCredentials(r.Spec.NamespaceCredentials, specPath.Child("namespaceCredentials"))...)
+	if r.Spec.ChartValues != nil && len(r.Spec.ChartValues.Raw) > 0 {
+		values := map[string]interface{}{}
+		if err := json.Unmarshal(r.Spec.ChartValues.Raw, &values); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("chartValues"), string(r.Spec.ChartValues.Raw), "must be an object"))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("BtpOperator").GroupKind(), r.Name, errs)
+}
+
+func validateCredentialsSecretRef(ref *CredentialsSecretRef, path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if ref == nil {
+		return errs
+	}
+	for _, msg := range validation.IsDNS1123Subdomain(ref.Name) {
+		errs = append(errs, field.Invalid(path.Child("name"), ref.Name, msg))
+	}
+	if ref.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(ref.Namespace) {
+			errs = append(errs, field.Invalid(path.Child("namespace"), ref.Namespace, msg))
+		}
+	}
+	for expectedKey, secretKey := range ref.KeyMapping {
+		keyPath := path.Child("keyMapping").Key(expectedKey)
+		if !isCredentialsKey(expectedKey) {
+			errs = append(errs, field.NotSupported(keyPath, expectedKey, CredentialsKeys))
+			continue
+		}
+		for _, msg := range validation.IsConfigMapKey(secretKey) {
+			errs = append(errs, field.Invalid(keyPath, secretKey, msg))
+		}
+	}
+
+	return errs
+}
+
+func validateNamespaceCredentials(credentials []NamespaceCredentials, path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	namespaces := make(map[string]struct{})
+	for i := range credentials {
+		entryPath := path.Index(i)
+		if len(credentials[i].Namespaces) == 0 {
+			errs = append(errs, field.Required(entryPath.Child("namespaces"), "at least one namespace is required"))
+		}
+		for j, namespace := range credentials[i].Namespaces {
+			namespacePath := entryPath.Child("namespaces").Index(j)
+			for _, msg := range validation.IsDNS1123Label(namespace) {
+				errs = append(errs, field.Invalid(namespacePath, namespace, msg))
+			}
+			if _, exists := namespaces[namespace]; exists {
+				errs = append(errs, field.Duplicate(namespacePath, namespace))
+			}
+			namespaces[namespace] = struct{}{}
+		}
+		secretRef := credentials[i].SecretRef
+		errs = append(errs, validateCredentialsSecretRef(&secretRef, entryPath.Child("secretRef"))...)
+	}
+
+	return errs
+}
+
+func validateDeploymentSettings(settings *DeploymentSettings, path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if settings == nil {
+		return errs
+	}
+	if settings.Replicas != nil && *settings.Replicas < 0 {
+		errs = append(errs, field.Invalid(path.Child("replicas"), *settings.Replicas, "must be greater than or equal to 0"))
+	}
+	if settings.PriorityClassName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(settings.PriorityClassName) {
+			errs = append(errs, field.Invalid(path.Child("priorityClassName"), settings.PriorityClassName, msg))
+		}
+	}
+	for key := range settings.NodeSelector {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, field.Invalid(path.Child("nodeSelector").Key(key), key, msg))
+		}
+	}
+	for i, toleration := range settings.Tolerations {
+		switch toleration.Operator {
+		case "", corev1.TolerationOpExists, corev1.TolerationOpEqual:
+		default:
+			errs = append(errs, field.NotSupported(path.Child("tolerations").Index(i).Child("operator"), toleration.Operator,
+				[]string{string(corev1.TolerationOpExists), string(corev1.TolerationOpEqual)}))
+		}
+	}
+
+	return errs
+}
+
+func isCredentialsKey(key string) bool {
+	for _, k := range CredentialsKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
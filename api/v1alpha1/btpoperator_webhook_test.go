@@ -0,0 +1,178 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateCreate(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, AddToScheme(s))
+	existing := &BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "btpoperator", Namespace: "kyma-system"}}
+
+	t.Run("should allow the first CR", func(t *testing.T) {
+		v := &BtpOperatorValidator{Reader: fake.NewClientBuilder().WithScheme(s).Build()}
+
+		err := v.ValidateCreate(context.Background(), existing.DeepCopy())
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject the second CR naming the existing one", func(t *testing.T) {
+		v := &BtpOperatorValidator{Reader: fake.NewClientBuilder().WithScheme(s).WithObjects(existing).Build()}
+		cr := &BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "default"}}
+
+		err := v.ValidateCreate(context.Background(), cr)
+
+		require.Error(t, err)
+		assert.True(t, apierrors.IsForbidden(err))
+		assert.Contains(t, err.Error(), "'btpoperator' BtpOperator CR in 'kyma-system' namespace already exists")
+	})
+
+	t.Run("should reject the CR with invalid spec", func(t *testing.T) {
+		v := &BtpOperatorValidator{Reader: fake.NewClientBuilder().WithScheme(s).Build()}
+		cr := existing.DeepCopy()
+		cr.Spec.CredentialsSecretRef = &CredentialsSecretRef{Name: "Invalid_Name"}
+
+		err := v.ValidateCreate(context.Background(), cr)
+
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), "spec.credentialsSecretRef.name")
+	})
+
+	t.Run("should reject objects other than BtpOperator", func(t *testing.T) {
+		v := &BtpOperatorValidator{Reader: fake.NewClientBuilder().WithScheme(s).Build()}
+
+		err := v.ValidateCreate(context.Background(), &corev1.Secret{})
+
+		assert.True(t, apierrors.IsBadRequest(err))
+	})
+}
+
+func TestValidateUpdate(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, AddToScheme(s))
+	existing := &BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "btpoperator", Namespace: "kyma-system"}}
+	v := &BtpOperatorValidator{Reader: fake.NewClientBuilder().WithScheme(s).WithObjects(existing).Build()}
+	updated := existing.DeepCopy()
+	replicas := int32(-1)
+	updated.Spec.Deployment = &DeploymentSettings{Replicas: &replicas}
+
+	// when
+	err := v.ValidateUpdate(context.Background(), existing, updated)
+
+	// then
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "spec.deployment.replicas")
+	assert.NoError(t, v.ValidateUpdate(context.Background(), existing, existing))
+}
+
+func TestValidateSpec(t *testing.T) {
+	replicas := int32(2)
+	tests := []struct {
+		name          string
+		spec          BtpOperatorSpec
+		expectedPaths []string
+	}{
+		{
+			name: "empty spec",
+		},
+		{
+			name: "valid spec",
+			spec: BtpOperatorSpec{
+				CredentialsSecretRef: &CredentialsSecretRef{
+					Name:       "credentials",
+					Namespace:  "team-a",
+					KeyMapping: map[string]string{"clientid": "client_id"},
+				},
+				Deployment: &DeploymentSettings{
+					Replicas:          &replicas,
+					NodeSelector:      map[string]string{"kubernetes.io/os": "linux"},
+					Tolerations:       []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+					PriorityClassName: "system-cluster-critical",
+				},
+				ChartValues: &apiextensionsv1.JSON{Raw: []byte(`{"manager":{"replica_count":2}}`)},
+			},
+		},
+		{
+			name: "invalid credentials Secret reference",
+			spec: BtpOperatorSpec{
+				CredentialsSecretRef: &CredentialsSecretRef{
+					Name:       "credentials",
+					Namespace:  "Team.A",
+					KeyMapping: map[string]string{"client": "client_id", "clientsecret": "client secret"},
+				},
+			},
+			expectedPaths: []string{
+				"spec.credentialsSecretRef.namespace",
+				"spec.credentialsSecretRef.keyMapping[client]",
+				"spec.credentialsSecretRef.keyMapping[clientsecret]",
+			},
+		},
+		{
+			name: "invalid namespace credentials",
+			spec: BtpOperatorSpec{
+				NamespaceCredentials: []NamespaceCredentials{
+					{Namespaces: []string{"team-a", "Team_B"}, SecretRef: CredentialsSecretRef{Name: "team-a"}},
+					{Namespaces: []string{"team-a"}, SecretRef: CredentialsSecretRef{Name: "other", KeyMapping: map[string]string{"client": "id"}}},
+					{SecretRef: CredentialsSecretRef{Name: "empty"}},
+				},
+			},
+			expectedPaths: []string{
+				"spec.namespaceCredentials[0].namespaces[1]",
+				"spec.namespaceCredentials[1].namespaces[0]",
+				"spec.namespaceCredentials[1].secretRef.keyMapping[client]",
+				"spec.namespaceCredentials[2].namespaces",
+			},
+		},
+		{
+			name: "invalid deployment settings",
+			spec: BtpOperatorSpec{
+				Deployment: &DeploymentSettings{
+					NodeSelector:      map[string]string{"invalid key!": "value"},
+					Tolerations:       []corev1.Toleration{{Key: "dedicated", Operator: "Unknown"}},
+					PriorityClassName: "Critical",
+				},
+			},
+			expectedPaths: []string{
+				"spec.deployment.nodeSelector[invalid key!]",
+				"spec.deployment.tolerations[0].operator",
+				"spec.deployment.priorityClassName",
+			},
+		},
+		{
+			name:          "chart values which are not an object",
+			spec:          BtpOperatorSpec{ChartValues: &apiextensionsv1.JSON{Raw: []byte(`["value"]`)}},
+			expectedPaths: []string{"spec.chartValues"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "btpoperator"}, Spec: tt.spec}
+
+			err := cr.validateSpec()
+
+			if len(tt.expectedPaths) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, path := range tt.expectedPaths {
+				assert.Contains(t, err.Error(), path)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ApplyDiff is the reason of the event listing module resources changed by the apply
+	ApplyDiff Reason = "ApplyDiff"
+	// FieldOwnershipForced is the reason of the event listing fields taken over from other field managers
+	FieldOwnershipForced Reason = "FieldOwnershipForced"
+
+	forcedConflict  = "forced"
+	refusedConflict = "refused"
+)
+
+// conflictManagerRegexp matches the field manager in the message of the server-side apply conflict cause,
+// for example: conflict with "kubectl-edit" using apps/v1
+var conflictManagerRegexp = regexp.MustCompile(`^conflict with "([^"]*)"`)
+
+// ignoredDiffFields are changed by every apply or by the API server, so they are not reported as differences
+var ignoredDiffFields = map[string]struct{}{
+	".metadata.managedFields":     {},
+	".metadata.resourceVersion":   {},
+	".metadata.generation":        {},
+	".metadata.creationTimestamp": {},
+	".metadata.uid":               {},
+	".status":                     {},
+}
+
+// fieldConflict is a field of a module resource owned by another field manager
+type fieldConflict struct {
+	Manager string
+	Field   string
+}
+
+// resourceDiff is the result of the server-side apply dry run of a module resource
+type resourceDiff struct {
+	Resource  *unstructured.Unstructured
+	Created   bool
+	Changed   []string
+	Conflicts []fieldConflict
+}
+
+func (d *resourceDiff) empty() bool {
+	return !d.Created && len(d.Changed) == 0 && len(d.Conflicts) == 0
+}
+
+// dryRunEnabled tells whether the module resources are applied with a dry run first
+func (c Config) dryRunEnabled() bool {
+	return c.ApplyDiff || len(c.ProtectedFieldManagers) > 0
+}
+
+// diffResource applies the module resource with a server-side apply dry run and returns the changed fields
+// and the fields owned by other field managers. If a conflicting field is owned by one of the protected field managers,
+// the ownership is not forced and an error with the FieldOwnershipConflict reason is returned.
+func (r *BtpOperatorReconciler) diffResource(ctx context.Context, u *unstructured.Unstructured, protected []string) (*resourceDiff, error) {
+	diff := &resourceDiff{Resource: u}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(u.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(u), current); err != nil {
+		if k8serrors.IsNotFound(err) {
+			diff.Created = true
+			return diff, nil
+		}
+		return nil, fmt.Errorf("while getting current state: %w", err)
+	}
+
+	applied := u.DeepCopy()
+	err := r.Patch(ctx, applied, client.Apply, client.DryRunAll, client.FieldOwner(operatorName))
+	if k8serrors.IsConflict(err) {
+		diff.Conflicts = fieldManagerConflicts(err)
+		if err := refuseProtectedConflicts(diff.Conflicts, protected); err != nil {
+			return diff, err
+		}
+		applied = u.DeepCopy()
+		err = r.Patch(ctx, applied, client.Apply, client.DryRunAll, client.ForceOwnership, client.FieldOwner(operatorName))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while applying with dry run: %w", err)
+	}
+
+	diff.Changed = diffFields("", current.Object, applied.Object)
+	return diff, nil
+}
+
+// fieldManagerConflicts returns the conflicts listed in the causes of the server-side apply conflict error
+func fieldManagerConflicts(err error) []fieldConflict {
+	var apiStatus k8serrors.APIStatus
+	if !errors.As(err, &apiStatus) || apiStatus.Status().Details == nil {
+		return nil
+	}
+	conflicts := make([]fieldConflict, 0)
+	for _, cause := range apiStatus.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		manager := cause.Message
+		if match := conflictManagerRegexp.FindStringSubmatch(cause.Message); match != nil {
+			manager = match[1]
+		}
+		conflicts = append(conflicts, fieldConflict{Manager: manager, Field: cause.Field})
+	}
+	return conflicts
+}
+
+func refuseProtectedConflicts(conflicts []fieldConflict, protected []string) error {
+	refused := make([]string, 0)
+	for _, conflict := range conflicts {
+		for _, manager := range protected {
+			if conflict.Manager == manager {
+				fieldManagerConflictsCounter.WithLabelValues(conflict.Manager, refusedConflict).Inc()
+				refused = append(refused, fmt.Sprintf("%s owned by %s", conflict.Field, conflict.Manager))
+				break
+			}
+		}
+	}
+	if len(refused) == 0 {
+		return nil
+	}
+	return NewErrorWithReason(FieldOwnershipConflict,
+		fmt.Sprintf("refused to force ownership of fields owned by protected field managers: %s", strings.Join(refused, ", ")))
+}
+
+// diffFields returns the paths of the fields which differ between the current and the applied object.
+// Lists are compared as a whole. Only the paths are returned, so the values of Secrets are not exposed.
+func diffFields(path string, current, applied map[string]interface{}) []string {
+	changed := make([]string, 0)
+	for key := range mergedKeys(current, applied) {
+		fieldPath := fmt.Sprintf("%s.%s", path, key)
+		if _, ignored := ignoredDiffFields[fieldPath]; ignored {
+			continue
+		}
+		currentValue, currentFound := current[key]
+		appliedValue, appliedFound := applied[key]
+		currentMap, currentIsMap := currentValue.(map[string]interface{})
+		appliedMap, appliedIsMap := appliedValue.(map[string]interface{})
+		switch {
+		case currentFound && appliedFound && currentIsMap && appliedIsMap:
+			changed = append(changed, diffFields(fieldPath, currentMap, appliedMap)...)
+		case currentFound != appliedFound || !equality.Semantic.DeepEqual(currentValue, appliedValue):
+			changed = append(changed, fieldPath)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func mergedKeys(maps ...map[string]interface{}) map[string]struct{} {
+	keys := make(map[string]struct{})
+	for _, m := range maps {
+		for key := range m {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// reportApplyDiff logs the dry run result of every module resource and emits the ApplyDiff event listing the changed
+// resources and the FieldOwnershipForced event listing the fields taken over from other field managers
+func (r *BtpOperatorReconciler) reportApplyDiff(ctx context.Context, cr *v1alpha1.BtpOperator, diffs []*resourceDiff) {
+	logger := log.FromContext(ctx)
+	changed := make([]string, 0)
+	forced := make([]string, 0)
+	for _, diff := range diffs {
+		if diff.empty() {
+			continue
+		}
+		u := diff.Resource
+		logger.Info("module resource apply diff", "kind", u.GetKind(), "namespace", u.GetNamespace(), "name", u.GetName(),
+			"created", diff.Created, "changed", diff.Changed, "conflicts", diff.Conflicts)
+		name := resourceName(u)
+		switch {
+		case diff.Created:
+			changed = append(changed, fmt.Sprintf("%s (created)", name))
+		case len(diff.Changed) > 0:
+			changed = append(changed, fmt.Sprintf("%s (%d fields)", name, len(diff.Changed)))
+		}
+		for _, conflict := range diff.Conflicts {
+			fieldManagerConflictsCounter.WithLabelValues(conflict.Manager, forcedConflict).Inc()
+			forced = append(forced, fmt.Sprintf("%s %s from %s", name, conflict.Field, conflict.Manager))
+		}
+	}
+	if len(changed) > 0 && r.config().ApplyDiff {
+		sort.Strings(changed)
+		r.recordEvent(cr, corev1.EventTypeNormal, ApplyDiff,
+			fmt.Sprintf("Module resources changed by the apply: %s", strings.Join(changed, ", ")))
+	}
+	if len(forced) > 0 {
+		sort.Strings(forced)
+		r.recordEvent(cr, corev1.EventTypeWarning, FieldOwnershipForced,
+			fmt.Sprintf("Forced ownership of fields owned by other field managers: %s", strings.Join(forced, ", ")))
+	}
+}
+
+func resourceName(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", u.GetKind(), u.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName())
+}
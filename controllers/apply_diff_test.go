@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// dryRunClient simulates the server-side apply dry run, which is not supported by the fake client
+type dryRunClient struct {
+	client.Client
+	conflicts []metav1.StatusCause
+	forced    bool
+}
+
+func (c *dryRunClient) Patch(_ context.Context, obj client.Object, _ client.Patch, opts ...client.PatchOption) error {
+	patchOpts := &client.PatchOptions{}
+	patchOpts.ApplyOptions(opts)
+	if len(c.conflicts) > 0 && (patchOpts.Force == nil || !*patchOpts.Force) {
+		return &k8serrors.StatusError{ErrStatus: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    409,
+			Reason:  metav1.StatusReasonConflict,
+			Details: &metav1.StatusDetails{Causes: c.conflicts},
+		}}
+	}
+	c.forced = patchOpts.Force != nil && *patchOpts.Force
+	return nil
+}
+
+func applyDiffTestConfigMap(data map[string]interface{}) *unstructured.Unstructured {
+	u := testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: configMapKind}, kymaNamespace, btpServiceOperatorConfigMap)
+	u.Object["data"] = data
+	return u
+}
+
+func TestDiffResource(t *testing.T) {
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: btpServiceOperatorConfigMap, Namespace: kymaNamespace},
+		Data:       map[string]string{"CLUSTER_ID": "old", "REMOVED": "value"},
+	}
+	conflict := metav1.StatusCause{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl-edit" using v1`, Field: ".data.CLUSTER_ID"}
+
+	t.Run("changed fields", func(t *testing.T) {
+		// given
+		c := &dryRunClient{Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing.DeepCopy()).Build()}
+		r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+		u := applyDiffTestConfigMap(map[string]interface{}{"CLUSTER_ID": "new", "ADDED": "value"})
+
+		// when
+		diff, err := r.diffResource(context.Background(), u, nil)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, diff.Created)
+		assert.Equal(t, []string{".data.ADDED", ".data.CLUSTER_ID", ".data.REMOVED"}, diff.Changed)
+		assert.Empty(t, diff.Conflicts)
+		assert.False(t, c.forced)
+	})
+
+	t.Run("created resource", func(t *testing.T) {
+		// given
+		c := &dryRunClient{Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()}
+		r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+
+		// when
+		diff, err := r.diffResource(context.Background(), applyDiffTestConfigMap(nil), nil)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, diff.Created)
+	})
+
+	t.Run("conflict with not protected field manager", func(t *testing.T) {
+		// given
+		c := &dryRunClient{
+			Client:    fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing.DeepCopy()).Build(),
+			conflicts: []metav1.StatusCause{conflict},
+		}
+		r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+
+		// when
+		diff, err := r.diffResource(context.Background(), applyDiffTestConfigMap(map[string]interface{}{"CLUSTER_ID": "new"}), []string{"other-controller"})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []fieldConflict{{Manager: "kubectl-edit", Field: ".data.CLUSTER_ID"}}, diff.Conflicts)
+		assert.True(t, c.forced)
+	})
+
+	t.Run("conflict with protected field manager", func(t *testing.T) {
+		// given
+		c := &dryRunClient{
+			Client:    fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing.DeepCopy()).Build(),
+			conflicts: []metav1.StatusCause{conflict},
+		}
+		r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+		refused := testutil.ToFloat64(fieldManagerConflictsCounter.WithLabelValues("kubectl-edit", refusedConflict))
+
+		// when
+		_, err := r.diffResource(context.Background(), applyDiffTestConfigMap(map[string]interface{}{"CLUSTER_ID": "new"}), []string{"kubectl-edit"})
+
+		// then
+		require.Error(t, err)
+		assert.Equal(t, FieldOwnershipConflict, reasonOf(err, ReconcileFailed))
+		assert.Contains(t, err.Error(), ".data.CLUSTER_ID owned by kubectl-edit")
+		assert.False(t, c.forced)
+		assert.Equal(t, refused+1, testutil.ToFloat64(fieldManagerConflictsCounter.WithLabelValues("kubectl-edit", refusedConflict)))
+	})
+}
+
+func TestDiffFields(t *testing.T) {
+	current := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "a", "resourceVersion": "1", "labels": map[string]interface{}{"a": "b"}},
+		"spec":     map[string]interface{}{"replicas": int64(1), "containers": []interface{}{"a"}},
+		"status":   map[string]interface{}{"ready": true},
+	}
+	applied := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "a", "resourceVersion": "2", "labels": map[string]interface{}{"a": "c"}},
+		"spec":     map[string]interface{}{"replicas": int64(1), "containers": []interface{}{"a", "b"}},
+		"status":   map[string]interface{}{"ready": false},
+	}
+
+	assert.Equal(t, []string{".metadata.labels.a", ".spec.containers"}, diffFields("", current, applied))
+	assert.Empty(t, diffFields("", current, current))
+}
+
+func TestReportApplyDiff(t *testing.T) {
+	// given
+	recorder := record.NewFakeRecorder(3)
+	r := NewBtpOperatorReconciler(nil, clientgoscheme.Scheme)
+	r.Recorder = recorder
+	cfg := DefaultConfig()
+	cfg.ApplyDiff = true
+	r.SetConfig(cfg)
+	u := applyDiffTestConfigMap(nil)
+	diffs := []*resourceDiff{
+		{Resource: u, Changed: []string{".data.CLUSTER_ID"}, Conflicts: []fieldConflict{{Manager: "kubectl-edit", Field: ".data.CLUSTER_ID"}}},
+		{Resource: testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: secretKind}, kymaNamespace, "unchanged")},
+	}
+	forced := testutil.ToFloat64(fieldManagerConflictsCounter.WithLabelValues("kubectl-edit", forcedConflict))
+
+	// when
+	r.reportApplyDiff(context.Background(), &v1alpha1.BtpOperator{}, diffs)
+
+	// then
+	require.Len(t, recorder.Events, 2)
+	assert.Equal(t, "Normal ApplyDiff Module resources changed by the apply: ConfigMap kyma-system/sap-btp-operator-config (1 fields)", <-recorder.Events)
+	assert.Equal(t, "Warning FieldOwnershipForced Forced ownership of fields owned by other field managers: ConfigMap kyma-system/sap-btp-operator-config .data.CLUSTER_ID from kubectl-edit", <-recorder.Events)
+	assert.Equal(t, forced+1, testutil.ToFloat64(fieldManagerConflictsCounter.WithLabelValues("kubectl-edit", forcedConflict)))
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	namespaceKind          = "Namespace"
+	serviceAccountKind     = "ServiceAccount"
+	clusterRoleKind        = "ClusterRole"
+	clusterRoleBindingKind = "ClusterRoleBinding"
+	roleKind               = "Role"
+	roleBindingKind        = "RoleBinding"
+	serviceKind            = "Service"
+)
+
+// applyPhase is a group of module resources applied together. The next phase starts only if every resource of the phase
+// has been applied and, if required, has become ready.
+type applyPhase struct {
+	name              string
+	kinds             []string
+	waitForReadiness  bool
+	resources         []*unstructured.Unstructured
+	acceptsOtherKinds bool
+}
+
+// newApplyPhases returns the phases in the order of dependencies: CRDs must be established before custom resources are applied,
+// and webhooks are registered only after the Deployment serving them is ready, so they never point at a Service without endpoints
+func newApplyPhases() []*applyPhase {
+	return []*applyPhase{
+		{name: "namespaces", kinds: []string{namespaceKind}},
+		{name: "crds", kinds: []string{crdKind}, waitForReadiness: true},
+		{name: "rbac", kinds: []string{serviceAccountKind, clusterRoleKind, clusterRoleBindingKind, roleKind, roleBindingKind}},
+		{name: "config", kinds: []string{configMapKind, secretKind}},
+		{name: "services", kinds: []string{serviceKind}},
+		{name: "other", acceptsOtherKinds: true},
+		{name: "deployments", kinds: []string{deploymentKind}, waitForReadiness: true},
+		{name: "webhooks", kinds: []string{mutatingWebhookKind, validatingWebhookKind}},
+	}
+}
+
+// groupIntoApplyPhases assigns the resources to the phases keeping their order within a phase and skips empty phases
+func groupIntoApplyPhases(us []*unstructured.Unstructured) []*applyPhase {
+	phases := newApplyPhases()
+	phaseOfKind := make(map[string]*applyPhase)
+	var otherPhase *applyPhase
+	for _, phase := range phases {
+		for _, kind := range phase.kinds {
+			phaseOfKind[kind] = phase
+		}
+		if phase.acceptsOtherKinds {
+			otherPhase = phase
+		}
+	}
+
+	for _, u := range us {
+		phase, found := phaseOfKind[u.GetKind()]
+		if !found {
+			phase = otherPhase
+		}
+		phase.resources = append(phase.resources, u)
+	}
+
+	result := make([]*applyPhase, 0, len(phases))
+	for _, phase := range phases {
+		if len(phase.resources) > 0 {
+			result = append(result, phase)
+		}
+	}
+	return result
+}
+
+// applyPhaseError aggregates the errors of all resources of the phase which failed to apply
+type applyPhaseError struct {
+	phase string
+	errs  []error
+}
+
+func (e *applyPhaseError) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d resource(s) failed in %s phase: %s", len(e.errs), e.phase, strings.Join(msgs, "; "))
+}
+
+// As lets errors.As find an error of any of the failed resources, for example the ErrorWithReason
+func (e *applyPhaseError) As(target interface{}) bool {
+	for _, err := range e.errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// recordingApplyClient records the applied resources and fails to apply the ones listed in failing
+type recordingApplyClient struct {
+	client.Client
+	applied []string
+	failing map[string]error
+}
+
+func (c *recordingApplyClient) Patch(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+	c.applied = append(c.applied, obj.GetName())
+	return c.failing[obj.GetName()]
+}
+
+func TestGroupIntoApplyPhases(t *testing.T) {
+	// given
+	us := []*unstructured.Unstructured{
+		testUnstructured(schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: mutatingWebhookKind}, "", "webhook"),
+		testUnstructured(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: deploymentKind}, kymaNamespace, "deployment"),
+		testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: serviceKind}, kymaNamespace, "service"),
+		testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: secretKind}, kymaNamespace, "secret"),
+		testUnstructured(schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"}, "", "priority-class"),
+		testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: configMapKind}, kymaNamespace, "config-map"),
+		testUnstructured(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: clusterRoleKind}, "", "cluster-role"),
+		testUnstructured(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: crdKind}, "", "crd"),
+		testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: namespaceKind}, "", kymaNamespace),
+	}
+
+	// when
+	phases := groupIntoApplyPhases(us)
+
+	// then
+	got := make(map[string][]string)
+	names := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		names = append(names, phase.name)
+		for _, u := range phase.resources {
+			got[phase.name] = append(got[phase.name], u.GetName())
+		}
+	}
+	assert.Equal(t, []string{"namespaces", "crds", "rbac", "config", "services", "other", "deployments", "webhooks"}, names)
+	assert.Equal(t, []string{"secret", "config-map"}, got["config"])
+	assert.Equal(t, []string{"priority-class"}, got["other"])
+	assert.True(t, phases[1].waitForReadiness)
+	assert.True(t, phases[6].waitForReadiness)
+	assert.Empty(t, groupIntoApplyPhases(nil))
+}
+
+func TestPhaseErrorsAreAggregated(t *testing.T) {
+	// given
+	c := &recordingApplyClient{
+		Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build(),
+		failing: map[string]error{
+			"secret":     errors.New("secret rejected"),
+			"config-map": NewErrorWithReason(FieldOwnershipConflict, "config map conflict"),
+		},
+	}
+	r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+	us := []*unstructured.Unstructured{
+		testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: serviceKind}, kymaNamespace, "service"),
+		testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: secretKind}, kymaNamespace, "secret"),
+		testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: configMapKind}, kymaNamespace, "config-map"),
+		testUnstructured(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: roleKind}, kymaNamespace, "role"),
+	}
+	cr := &v1alpha1.BtpOperator{}
+	r.setResourcesStatus(cr, us)
+
+	// when
+	err := r.applyResources(context.Background(), cr, us)
+
+	// then
+	require.Error(t, err)
+	assert.Equal(t, []string{"role", "secret", "config-map"}, c.applied, "all resources of the failed phase should be applied, the next phases should not")
+	assert.Equal(t, "2 resource(s) failed in config phase: while applying secret Secret: secret rejected; while applying config-map ConfigMap: config map conflict", err.Error())
+	assert.Equal(t, FieldOwnershipConflict, reasonOf(err, ReconcileFailed))
+	for _, resource := range cr.Status.Resources {
+		assert.Equal(t, resource.Name == "secret" || resource.Name == "config-map", resource.LastError != "", resource.Name)
+	}
+}
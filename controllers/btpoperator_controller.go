@@ -19,27 +19,34 @@ package controllers
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kyma-project/btp-manager/api/v1alpha1"
 	"github.com/kyma-project/btp-manager/internal/manifest"
+	"github.com/kyma-project/btp-manager/internal/renderer"
 	"github.com/kyma-project/btp-manager/internal/ymlutils"
 	"github.com/kyma-project/module-manager/pkg/types"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8sgenerictypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -52,22 +59,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
-// Configuration options that can be overwritten either by CLI parameter or ConfigMap
-var (
-	ChartNamespace                 = "kyma-system"
-	SecretName                     = "sap-btp-manager"
-	ConfigName                     = "sap-btp-manager"
-	DeploymentName                 = "sap-btp-operator-controller-manager"
-	ProcessingStateRequeueInterval = time.Minute * 5
-	ReadyStateRequeueInterval      = time.Minute * 15
-	ReadyTimeout                   = time.Minute * 1
-	ReadyCheckInterval             = time.Second * 2
-	HardDeleteTimeout              = time.Minute * 20
-	ChartPath                      = "./module-chart/chart"
-	HardDeleteCheckInterval        = time.Second * 10
-	ResourcesPath                  = "./module-resources"
-)
-
 const (
 	secretKind                  = "Secret"
 	configMapKind               = "ConfigMap"
@@ -78,6 +69,12 @@ const (
 	btpServiceOperatorSecret    = "sap-btp-service-operator"
 	mutatingWebhookName         = "sap-btp-operator-mutating-webhook-configuration"
 	validatingWebhookName       = "sap-btp-operator-validating-webhook-configuration"
+	deploymentKind              = "Deployment"
+	managerContainerName        = "manager"
+	leaderElectionArg           = "--enable-leader-election"
+	chartReleaseName            = "sap-btp-operator"
+	webhookServerCertSecret     = "webhook-server-cert"
+	btpServiceOperatorTlsSecret = "sap-btp-service-operator-tls"
 )
 
 const (
@@ -108,6 +105,8 @@ var (
 		Kind:    btpOperatorServiceInstance,
 	}
 	managedByLabelFilter = client.MatchingLabels{managedByLabelKey: operatorName}
+	credentialsKeys      = v1alpha1.CredentialsKeys
+	requiredSecretKeys   = []string{"clientid", "sm_url", "cluster_id"}
 )
 
 // BtpOperatorReconciler reconciles a BtpOperator object
@@ -115,8 +114,15 @@ type BtpOperatorReconciler struct {
 	client.Client
 	*rest.Config
 	Scheme          *runtime.Scheme
+	Recorder        record.EventRecorder
 	manifestHandler *manifest.Handler
 	workqueueSize   int
+	configLock      sync.RWMutex
+	baseConfig      *Config
+	currentConfig   *Config
+	driftLock       sync.Mutex
+	drifted         map[string]drift
+	ownWrites       map[string]string
 }
 
 func NewBtpOperatorReconciler(client client.Client, scheme *runtime.Scheme) *BtpOperatorReconciler {
@@ -131,6 +137,8 @@ func NewBtpOperatorReconciler(client client.Client, scheme *runtime.Scheme) *Btp
 //+kubebuilder:rbac:groups="operator.kyma-project.io",resources="btpoperators",verbs="*"
 //+kubebuilder:rbac:groups="operator.kyma-project.io",resources="btpoperators/status",verbs="*"
 //+kubebuilder:rbac:groups="",resources="namespaces",verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources="endpoints",verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources="events",verbs=create;patch
 //+kubebuilder:rbac:groups="services.cloud.sap.com",resources=serviceinstances;servicebindings,verbs="*"
 
 // Autogenerated RBAC from the btp-operator chart
@@ -155,6 +163,7 @@ func (r *BtpOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if err := r.Get(ctx, req.NamespacedName, cr); err != nil {
 		if k8serrors.IsNotFound(err) {
 			logger.Info("BtpOperator CR not found. Ignoring since object has been deleted.")
+			deleteBtpOperatorState(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		logger.Error(err, "unable to get BtpOperator CR")
@@ -181,20 +190,36 @@ func (r *BtpOperatorReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	if !cr.ObjectMeta.DeletionTimestamp.IsZero() && cr.Status.State != types.StateDeleting {
-		return ctrl.Result{}, r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, HardDeleting, "BtpOperator is to be deleted")
+		return ctrl.Result{}, r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, deletingReason(cr), "BtpOperator is to be deleted")
+	}
+
+	if cr.ObjectMeta.DeletionTimestamp.IsZero() && cr.GetAnnotations()[v1alpha1.DeprovisioningPreviewAnnotation] == "true" {
+		return ctrl.Result{}, r.handleDeprovisioningPreview(ctx, cr)
+	}
+
+	if cr.ObjectMeta.DeletionTimestamp.IsZero() && cr.Status.State == types.StateReady &&
+		cr.GetAnnotations()[v1alpha1.RestoreServiceResourcesAnnotation] == "true" {
+		return ctrl.Result{}, r.handleServiceResourcesRestore(ctx, cr)
 	}
 
+	defer observeReconcileDuration(cr.Status.State, time.Now())
+
+	cfg := r.config()
 	switch cr.Status.State {
 	case "":
 		return ctrl.Result{}, r.HandleInitialState(ctx, cr)
 	case types.StateProcessing:
-		return ctrl.Result{RequeueAfter: ProcessingStateRequeueInterval}, r.HandleProcessingState(ctx, cr)
+		return ctrl.Result{RequeueAfter: cfg.ProcessingStateRequeueInterval}, r.HandleProcessingState(ctx, cr)
 	case types.StateError:
 		return ctrl.Result{}, r.HandleErrorState(ctx, cr)
 	case types.StateDeleting:
-		return ctrl.Result{}, r.HandleDeletingState(ctx, cr)
+		blocked, err := r.handleDeletionProtection(ctx, cr)
+		if err != nil || blocked {
+			return ctrl.Result{RequeueAfter: cfg.DeletionBlockedRequeueInterval}, err
+		}
+		return r.HandleDeletingState(ctx, cr)
 	case types.StateReady:
-		return ctrl.Result{RequeueAfter: ReadyStateRequeueInterval}, r.HandleReadyState(ctx, cr)
+		return ctrl.Result{RequeueAfter: cfg.ReadyStateRequeueInterval}, r.HandleReadyState(ctx, cr)
 	}
 
 	return ctrl.Result{}, nil
@@ -224,7 +249,31 @@ func (r *BtpOperatorReconciler) UpdateBtpOperatorStatus(ctx context.Context, cr
 	if newCondition != nil {
 		SetStatusCondition(&cr.Status.Conditions, *newCondition)
 	}
-	return r.Status().Update(ctx, cr)
+	if err := r.Status().Update(ctx, cr); err != nil {
+		return err
+	}
+	if newState == types.StateError {
+		reconcileFailuresCounter.WithLabelValues(string(reason)).Inc()
+	}
+	r.recordEvent(cr, eventTypeForStatus(newState, reason), reason, message)
+	recordBtpOperatorState(cr)
+	return nil
+}
+
+// recordEvent emits an event on the BtpOperator CR, reasons from conditions.go are used as event reasons
+func (r *BtpOperatorReconciler) recordEvent(cr *v1alpha1.BtpOperator, eventType string, reason Reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(cr, eventType, string(reason), message)
+}
+
+// eventTypeForStatus returns Warning for the Error state and for soft delete, which is the fallback after hard delete failure
+func eventTypeForStatus(state types.State, reason Reason) string {
+	if state == types.StateError || reason == SoftDeleting || reason == DeletionBlocked || reason == ServiceResourcesBackupFailed {
+		return corev1.EventTypeWarning
+	}
+	return corev1.EventTypeNormal
 }
 
 func (r *BtpOperatorReconciler) HandleInitialState(ctx context.Context, cr *v1alpha1.BtpOperator) error {
@@ -237,7 +286,12 @@ func (r *BtpOperatorReconciler) HandleProcessingState(ctx context.Context, cr *v
 	logger := log.FromContext(ctx)
 	logger.Info("Handling Processing state")
 
-	secret, errWithReason := r.getAndVerifyRequiredSecret(ctx)
+	secret, errWithReason := r.getAndVerifyRequiredSecret(ctx, cr)
+	if errWithReason != nil {
+		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, errWithReason.reason, errWithReason.message)
+	}
+
+	namespaceCredentials, errWithReason := r.getNamespaceCredentialsResources(ctx, cr)
 	if errWithReason != nil {
 		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, errWithReason.reason, errWithReason.message)
 	}
@@ -246,22 +300,28 @@ func (r *BtpOperatorReconciler) HandleProcessingState(ctx context.Context, cr *v
 		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, ProvisioningFailed, err.Error())
 	}
 
-	if err := r.reconcileResources(ctx, secret); err != nil {
-		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, ProvisioningFailed, err.Error())
+	if err := r.reconcileResources(ctx, cr, secret, namespaceCredentials); err != nil {
+		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, reasonOf(err, ProvisioningFailed), err.Error())
 	}
 
 	logger.Info("provisioning succeeded")
 	return r.UpdateBtpOperatorStatus(ctx, cr, types.StateReady, ReconcileSucceeded, "Module provisioning succeeded")
 }
 
-func (r *BtpOperatorReconciler) getAndVerifyRequiredSecret(ctx context.Context) (*corev1.Secret, *ErrorWithReason) {
+func (r *BtpOperatorReconciler) getAndVerifyRequiredSecret(ctx context.Context, cr *v1alpha1.BtpOperator) (*corev1.Secret, *ErrorWithReason) {
 	logger := log.FromContext(ctx)
 
-	logger.Info("getting the required Secret")
-	secret, err := r.getRequiredSecret(ctx)
+	if err := r.validateCredentialsSecretRef(cr.Spec.CredentialsSecretRef); err != nil {
+		logger.Error(err, "while validating the credentials Secret reference")
+		return nil, NewErrorWithReason(InvalidCredentialsSecretRef, err.Error())
+	}
+
+	objKey := r.credentialsSecretKey(cr)
+	logger.Info("getting the required Secret", "name", objKey.Name, "namespace", objKey.Namespace)
+	secret, err := r.getRequiredSecret(ctx, cr)
 	if err != nil {
 		logger.Error(err, "while getting the required Secret")
-		return nil, NewErrorWithReason(MissingSecret, "Secret resource not found")
+		return nil, NewErrorWithReason(MissingSecret, fmt.Sprintf("Secret resource %s in %s namespace not found", objKey.Name, objKey.Namespace))
 	}
 
 	logger.Info("verifying the required Secret")
@@ -269,28 +329,125 @@ func (r *BtpOperatorReconciler) getAndVerifyRequiredSecret(ctx context.Context)
 		logger.Error(err, "while verifying the required Secret")
 		return nil, NewErrorWithReason(InvalidSecret, "Secret validation failed")
 	}
+
+	if r.config().CredentialsCheck {
+		logger.Info("checking the credentials against the Service Manager")
+		if errWithReason := r.checkCredentials(ctx, secret); errWithReason != nil {
+			logger.Error(errWithReason, "while checking the credentials")
+			return nil, errWithReason
+		}
+	}
+
+	r.setCredentialsSecretCondition(cr, objKey)
+	r.setCertificateCondition(cr, secret)
 	return secret, nil
 }
 
-func (r *BtpOperatorReconciler) getRequiredSecret(ctx context.Context) (*corev1.Secret, error) {
+func (r *BtpOperatorReconciler) validateCredentialsSecretRef(ref *v1alpha1.CredentialsSecretRef) error {
+	if ref == nil {
+		return nil
+	}
+	if ref.Name == "" {
+		return errors.New("credentials Secret reference has no name")
+	}
+	unknownKeys := make([]string, 0)
+	emptyMappings := make([]string, 0)
+	for expectedKey, secretKey := range ref.KeyMapping {
+		if !containsString(credentialsKeys, expectedKey) {
+			unknownKeys = append(unknownKeys, expectedKey)
+			continue
+		}
+		if secretKey == "" {
+			emptyMappings = append(emptyMappings, expectedKey)
+		}
+	}
+	errs := make([]string, 0)
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
+		errs = append(errs, fmt.Sprintf("unknown key(s) %s in key mapping, expected one of %s", strings.Join(unknownKeys, ", "), strings.Join(credentialsKeys, ", ")))
+	}
+	if len(emptyMappings) > 0 {
+		sort.Strings(emptyMappings)
+		errs = append(errs, fmt.Sprintf("empty mapping for %s key(s)", strings.Join(emptyMappings, ", ")))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func (r *BtpOperatorReconciler) credentialsSecretKey(cr *v1alpha1.BtpOperator) client.ObjectKey {
+	if cr.Spec.CredentialsSecretRef == nil {
+		cfg := r.config()
+		return client.ObjectKey{Namespace: cfg.ChartNamespace, Name: cfg.SecretName}
+	}
+	return r.secretRefKey(cr.Spec.CredentialsSecretRef)
+}
+
+func (r *BtpOperatorReconciler) secretRefKey(ref *v1alpha1.CredentialsSecretRef) client.ObjectKey {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = r.config().ChartNamespace
+	}
+	return client.ObjectKey{Namespace: namespace, Name: ref.Name}
+}
+
+func (r *BtpOperatorReconciler) getRequiredSecret(ctx context.Context, cr *v1alpha1.BtpOperator) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
-	objKey := client.ObjectKey{Namespace: ChartNamespace, Name: SecretName}
+	objKey := r.credentialsSecretKey(cr)
 	if err := r.Get(ctx, objKey, secret); err != nil {
 		if k8serrors.IsNotFound(err) {
-			return nil, fmt.Errorf("%s Secret in %s namespace not found", SecretName, ChartNamespace)
+			return nil, fmt.Errorf("%s Secret in %s namespace not found", objKey.Name, objKey.Namespace)
 		}
 		return nil, fmt.Errorf("unable to get Secret: %w", err)
 	}
 
+	if cr.Spec.CredentialsSecretRef != nil {
+		return r.mapSecretKeys(secret, cr.Spec.CredentialsSecretRef.KeyMapping), nil
+	}
 	return secret, nil
 }
 
+// mapSecretKeys returns a copy of the Secret with data keys renamed according to the key mapping,
+// so that the rest of the reconciler can rely on the expected credentials keys
+func (r *BtpOperatorReconciler) mapSecretKeys(secret *corev1.Secret, keyMapping map[string]string) *corev1.Secret {
+	if len(keyMapping) == 0 {
+		return secret
+	}
+	mapped := secret.DeepCopy()
+	if mapped.Data == nil {
+		mapped.Data = make(map[string][]byte)
+	}
+	for expectedKey, secretKey := range keyMapping {
+		delete(mapped.Data, expectedKey)
+		delete(mapped.Data, secretKey)
+	}
+	for expectedKey, secretKey := range keyMapping {
+		if value, exists := secret.Data[secretKey]; exists {
+			mapped.Data[expectedKey] = value
+		}
+	}
+
+	return mapped
+}
+
+func (r *BtpOperatorReconciler) setCredentialsSecretCondition(cr *v1alpha1.BtpOperator, objKey client.ObjectKey) {
+	condition := ConditionFromExistingReason(CredentialsSecretResolved,
+		fmt.Sprintf("Using %s Secret from %s namespace", objKey.Name, objKey.Namespace))
+	SetStatusCondition(&cr.Status.Conditions, *condition)
+}
+
 func (r *BtpOperatorReconciler) verifySecret(secret *corev1.Secret) error {
+	return r.verifyCredentialsSecret(secret, requiredSecretKeys)
+}
+
+// verifyCredentialsSecret checks that the Secret holds values for the required keys and for its type of credentials
+func (r *BtpOperatorReconciler) verifyCredentialsSecret(secret *corev1.Secret, requiredKeys []string) error {
 	missingKeys := make([]string, 0)
 	missingValues := make([]string, 0)
 	errs := make([]string, 0)
-	requiredKeys := []string{"clientid", "clientsecret", "sm_url", "tokenurl", "cluster_id"}
-	for _, key := range requiredKeys {
+	for _, key := range credentialsKeysFor(secret, requiredKeys) {
 		value, exists := secret.Data[key]
 		if !exists {
 			missingKeys = append(missingKeys, key)
@@ -308,6 +465,9 @@ func (r *BtpOperatorReconciler) verifySecret(secret *corev1.Secret) error {
 		missingValuesMsg := fmt.Sprintf("missing value(s) for %s key(s)", strings.Join(missingValues, ", "))
 		errs = append(errs, missingValuesMsg)
 	}
+	if err := certificateCredentialsError(secret); err != nil {
+		errs = append(errs, err.Error())
+	}
 	if len(errs) > 0 {
 		return fmt.Errorf("%s", strings.Join(errs, ", "))
 	}
@@ -349,7 +509,7 @@ func (r *BtpOperatorReconciler) createUnstructuredObjectsFromManifestsDir(manife
 }
 
 func (r *BtpOperatorReconciler) getResourcesToDeletePath() string {
-	return fmt.Sprintf("%s%cdelete", ResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%cdelete", r.config().ResourcesPath, os.PathSeparator)
 }
 
 func (r *BtpOperatorReconciler) deleteResources(ctx context.Context, us []*unstructured.Unstructured) error {
@@ -374,31 +534,90 @@ func (r *BtpOperatorReconciler) deleteResources(ctx context.Context, us []*unstr
 	return nil
 }
 
-func (r *BtpOperatorReconciler) reconcileResources(ctx context.Context, s *corev1.Secret) error {
+func (r *BtpOperatorReconciler) reconcileResources(ctx context.Context, cr *v1alpha1.BtpOperator, s *corev1.Secret, namespaceCredentials []*unstructured.Unstructured) error {
 	logger := log.FromContext(ctx)
 
 	logger.Info("getting module resources to apply")
-	resourcesToApply, err := r.createUnstructuredObjectsFromManifestsDir(r.getResourcesToApplyPath())
+	resourcesToApply, err := r.getModuleResources(ctx, cr, s)
 	if err != nil {
 		logger.Error(err, "while creating applicable objects from manifests")
 		return fmt.Errorf("Failed to create applicable objects from manifests: %w", err)
 	}
 	logger.Info(fmt.Sprintf("got %d module resources to apply", len(resourcesToApply)))
 
+	if r.shouldRenderChart(ctx) {
+		if err = r.reuseWebhookCertificates(ctx, cr, resourcesToApply); err != nil {
+			logger.Error(err, "while reusing webhook certificates")
+			return fmt.Errorf("Failed to reuse webhook certificates: %w", err)
+		}
+	}
+
+	resourcesToApply = append(resourcesToApply, namespaceCredentials...)
+
 	logger.Info("preparing module resources to apply")
-	if err = r.prepareModuleResources(ctx, resourcesToApply, s); err != nil {
+	if err = r.prepareModuleResources(ctx, cr, resourcesToApply, s); err != nil {
 		logger.Error(err, "while preparing objects to apply")
 		return fmt.Errorf("Failed to prepare objects to apply: %w", err)
 	}
 
+	r.setResourcesStatus(cr, resourcesToApply)
+
+	logger.Info("checking CRD compatibility")
+	if err = r.checkCRDsCompatibility(ctx, resourcesToApply); err != nil {
+		var reasonErr *ErrorWithReason
+		if errors.As(err, &reasonErr) {
+			return err
+		}
+		logger.Error(err, "while checking CRD compatibility")
+		return fmt.Errorf("Failed to check CRD compatibility: %w", err)
+	}
+
+	lastKnownGood, err := r.getLastKnownGood(ctx)
+	if err != nil {
+		logger.Error(err, "while getting last-known-good module resources")
+		return fmt.Errorf("Failed to get last-known-good module resources: %w", err)
+	}
+
+	if err = r.applyModuleResources(ctx, cr, resourcesToApply); err != nil {
+		var notReadyErr *ResourcesNotReadyError
+		if errors.As(err, &notReadyErr) && shouldRollbackUpgrade(cr, lastKnownGood, resourcesToApply) {
+			return r.rollbackUpgrade(ctx, cr, lastKnownGood, resourcesToApply, notReadyErr)
+		}
+		return err
+	}
+
+	logger.Info("storing last-known-good module resources")
+	if err = r.saveLastKnownGood(ctx, resourcesToApply); err != nil {
+		logger.Error(err, "while storing last-known-good module resources")
+		return fmt.Errorf("Failed to store last-known-good module resources: %w", err)
+	}
+
+	return nil
+}
+
+// applyModuleResources applies the module resources, prunes the outdated ones and waits for the readiness
+func (r *BtpOperatorReconciler) applyModuleResources(ctx context.Context, cr *v1alpha1.BtpOperator, resourcesToApply []*unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+
 	logger.Info("applying module resources")
-	if err = r.applyResources(ctx, resourcesToApply); err != nil {
+	if err := r.applyResources(ctx, cr, resourcesToApply); err != nil {
+		moduleResourcesFailuresCounter.WithLabelValues(applyOperation).Inc()
 		logger.Error(err, "while applying module resources")
 		return fmt.Errorf("Failed to apply module resources: %w", err)
 	}
+	r.setCredentialsStatus(cr, resourcesToApply)
+	r.reportDriftCorrected(cr, resourcesToApply)
+
+	logger.Info("pruning outdated module resources")
+	if err := r.pruneResources(ctx, resourcesToApply); err != nil {
+		moduleResourcesFailuresCounter.WithLabelValues(pruneOperation).Inc()
+		logger.Error(err, "while pruning outdated module resources")
+		return fmt.Errorf("Failed to prune outdated module resources: %w", err)
+	}
 
 	logger.Info("waiting for module resources readiness")
-	if err = r.waitForResourcesReadiness(ctx, resourcesToApply); err != nil {
+	if err := r.waitForResourcesReadiness(ctx, cr, resourcesToApply); err != nil {
+		moduleResourcesFailuresCounter.WithLabelValues(readinessOperation).Inc()
 		logger.Error(err, "while waiting for module resources readiness")
 		return fmt.Errorf("Timed out while waiting for resources readiness: %w", err)
 	}
@@ -407,13 +626,191 @@ func (r *BtpOperatorReconciler) reconcileResources(ctx context.Context, s *corev
 }
 
 func (r *BtpOperatorReconciler) getResourcesToApplyPath() string {
-	return fmt.Sprintf("%s%capply", ResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%capply", r.config().ResourcesPath, os.PathSeparator)
 }
 
-func (r *BtpOperatorReconciler) prepareModuleResources(ctx context.Context, us []*unstructured.Unstructured, s *corev1.Secret) error {
+// getModuleResources renders the module chart or, if rendering is disabled or the chart templates are not available,
+// reads the pre-rendered manifests from the resources directory. The Secret is optional.
+func (r *BtpOperatorReconciler) getModuleResources(ctx context.Context, cr *v1alpha1.BtpOperator, s *corev1.Secret) ([]*unstructured.Unstructured, error) {
+	cfg := r.config()
+	if !r.shouldRenderChart(ctx) {
+		us, err := r.createUnstructuredObjectsFromManifestsDir(r.getResourcesToApplyPath())
+		if err != nil {
+			return nil, err
+		}
+		return withCredentialsTLSSecret(us, s, cfg.ChartNamespace), nil
+	}
+
+	values, err := r.getChartValues(cr, s)
+	if err != nil {
+		return nil, fmt.Errorf("while preparing chart values: %w", err)
+	}
+	manifests, err := renderer.RenderChart(cfg.ChartPath, chartReleaseName, cfg.ChartNamespace, values)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := r.manifestHandler.CreateObjectsFromManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	us, err := r.manifestHandler.ObjectsToUnstructured(objs)
+	if err != nil {
+		return nil, err
+	}
+	return withCredentialsTLSSecret(us, s, cfg.ChartNamespace), nil
+}
+
+func (r *BtpOperatorReconciler) shouldRenderChart(ctx context.Context) bool {
+	cfg := r.config()
+	if !cfg.RenderChart {
+		return false
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s%ctemplates", cfg.ChartPath, os.PathSeparator)); err != nil {
+		log.FromContext(ctx).Info("chart templates not available, falling back to pre-rendered module resources", "chartPath", cfg.ChartPath)
+		return false
+	}
+	return true
+}
+
+func (r *BtpOperatorReconciler) getChartValues(cr *v1alpha1.BtpOperator, s *corev1.Secret) (map[string]interface{}, error) {
+	overrides := make(map[string]interface{})
+	if overridesPath := r.config().ChartOverridesPath; overridesPath != "" {
+		var err error
+		overrides, err = renderer.ReadValuesFile(overridesPath)
+		if err != nil {
+			return nil, fmt.Errorf("while reading chart overrides from %s: %w", overridesPath, err)
+		}
+	}
+
+	specValues := make(map[string]interface{})
+	if cr.Spec.ChartValues != nil && len(cr.Spec.ChartValues.Raw) > 0 {
+		if err := json.Unmarshal(cr.Spec.ChartValues.Raw, &specValues); err != nil {
+			return nil, fmt.Errorf("while reading chart values from BtpOperator CR: %w", err)
+		}
+	}
+
+	credentials := make(map[string]interface{})
+	if s != nil {
+		secretValues := map[string]interface{}{
+			"clientid":     string(s.Data["clientid"]),
+			"clientsecret": string(s.Data["clientsecret"]),
+			"sm_url":       string(s.Data["sm_url"]),
+			"tokenurl":     string(s.Data["tokenurl"]),
+		}
+		if usesCertificateCredentials(s) {
+			secretValues["clientsecret"] = ""
+			secretValues["tokenurl"] = string(s.Data[certURLKey])
+			secretValues["tls"] = map[string]interface{}{
+				"crt": string(s.Data[corev1.TLSCertKey]),
+				"key": string(s.Data[corev1.TLSPrivateKeyKey]),
+			}
+		}
+		credentials = map[string]interface{}{
+			"manager": map[string]interface{}{
+				"secret": secretValues,
+			},
+			"cluster": map[string]interface{}{
+				"id": string(s.Data["cluster_id"]),
+			},
+		}
+	}
+
+	values := renderer.MergeValues(overrides, specValues, credentials)
+	dropDisabledCertificateModes(values)
+	return values, nil
+}
+
+// dropDisabledCertificateModes removes the webhook certificate modes turned off in the values, like certManager: false
+// in the module chart overrides. The chart generates the webhook certificates only if no mode is set.
+func dropDisabledCertificateModes(values map[string]interface{}) {
+	manager, _ := values["manager"].(map[string]interface{})
+	certificates, _ := manager["certificates"].(map[string]interface{})
+	for mode, value := range certificates {
+		if enabled, isBool := value.(bool); value == nil || (isBool && !enabled) {
+			delete(certificates, mode)
+		}
+	}
+}
+
+// reuseWebhookCertificates replaces the webhook certificates generated while rendering the chart with the ones already
+// present in the cluster, so that the certificates are not rotated on every reconciliation
+func (r *BtpOperatorReconciler) reuseWebhookCertificates(ctx context.Context, cr *v1alpha1.BtpOperator, us []*unstructured.Unstructured) error {
+	values, err := r.getChartValues(cr, nil)
+	if err != nil {
+		return err
+	}
+	if certificates, found, _ := unstructured.NestedMap(values, "manager", "certificates"); found && len(certificates) > 0 {
+		return nil
+	}
+
+	existingCert := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.config().ChartNamespace, Name: webhookServerCertSecret}, existingCert); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	crt, key := existingCert.Data[corev1.TLSCertKey], existingCert.Data[corev1.TLSPrivateKeyKey]
+	if len(crt) == 0 || len(key) == 0 {
+		return nil
+	}
+	mutatingWebhook := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := r.Get(ctx, client.ObjectKey{Name: mutatingWebhookName}, mutatingWebhook); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if len(mutatingWebhook.Webhooks) == 0 || len(mutatingWebhook.Webhooks[0].ClientConfig.CABundle) == 0 {
+		return nil
+	}
+	caBundle := base64.StdEncoding.EncodeToString(mutatingWebhook.Webhooks[0].ClientConfig.CABundle)
+
+	var renderedCrt string
+	for _, u := range us {
+		if u.GetKind() == secretKind && u.GetName() == webhookServerCertSecret {
+			renderedCrt, _, _ = unstructured.NestedString(u.Object, "data", corev1.TLSCertKey)
+		}
+	}
+	for _, u := range us {
+		switch u.GetKind() {
+		case secretKind:
+			if u.GetName() != webhookServerCertSecret && u.GetName() != btpServiceOperatorTlsSecret {
+				continue
+			}
+			if currentCrt, _, _ := unstructured.NestedString(u.Object, "data", corev1.TLSCertKey); currentCrt != renderedCrt {
+				continue
+			}
+			if err := unstructured.SetNestedField(u.Object, base64.StdEncoding.EncodeToString(crt), "data", corev1.TLSCertKey); err != nil {
+				return err
+			}
+			if err := unstructured.SetNestedField(u.Object, base64.StdEncoding.EncodeToString(key), "data", corev1.TLSPrivateKeyKey); err != nil {
+				return err
+			}
+		case "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration":
+			webhooks, _, err := unstructured.NestedSlice(u.Object, "webhooks")
+			if err != nil {
+				return err
+			}
+			for i := range webhooks {
+				webhook, ok := webhooks[i].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if err := unstructured.SetNestedField(webhook, caBundle, "clientConfig", "caBundle"); err != nil {
+					return err
+				}
+			}
+			if err := unstructured.SetNestedSlice(u.Object, webhooks, "webhooks"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *BtpOperatorReconciler) prepareModuleResources(ctx context.Context, cr *v1alpha1.BtpOperator, us []*unstructured.Unstructured, s *corev1.Secret) error {
 	logger := log.FromContext(ctx)
+	cfg := r.config()
 
 	var configMapIndex, secretIndex int
+	deploymentIndex, tlsSecretIndex := -1, -1
 	for i, u := range us {
 		if u.GetName() == btpServiceOperatorConfigMap && u.GetKind() == configMapKind {
 			configMapIndex = i
@@ -421,9 +818,15 @@ func (r *BtpOperatorReconciler) prepareModuleResources(ctx context.Context, us [
 		if u.GetName() == btpServiceOperatorSecret && u.GetKind() == secretKind {
 			secretIndex = i
 		}
+		if u.GetName() == cfg.DeploymentName && u.GetKind() == deploymentKind {
+			deploymentIndex = i
+		}
+		if u.GetName() == btpServiceOperatorTlsSecret && u.GetKind() == secretKind {
+			tlsSecretIndex = i
+		}
 	}
 
-	chartVer, err := ymlutils.ExtractStringValueFromYamlForGivenKey(fmt.Sprintf("%s/Chart.yaml", ChartPath), "version")
+	chartVer, err := ymlutils.ExtractStringValueFromYamlForGivenKey(fmt.Sprintf("%s/Chart.yaml", cfg.ChartPath), "version")
 	if err != nil {
 		logger.Error(err, "while getting module chart version")
 		return fmt.Errorf("Failed to get module chart version: %w", err)
@@ -440,6 +843,31 @@ func (r *BtpOperatorReconciler) prepareModuleResources(ctx context.Context, us [
 		logger.Error(err, "while setting Secret values")
 		return fmt.Errorf("Failed to set Secret values: %w", err)
 	}
+	credentialsResources := []*unstructured.Unstructured{us[secretIndex], us[configMapIndex]}
+	if usesCertificateCredentials(s) && tlsSecretIndex >= 0 {
+		if err := r.setTLSSecretValues(s, us[tlsSecretIndex]); err != nil {
+			logger.Error(err, "while setting TLS Secret values")
+			return fmt.Errorf("Failed to set TLS Secret values: %w", err)
+		}
+		credentialsResources = append(credentialsResources, us[tlsSecretIndex])
+	}
+	if cr.Spec.Deployment != nil && deploymentIndex >= 0 {
+		if err := r.setDeploymentSettings(cr.Spec.Deployment, us[deploymentIndex]); err != nil {
+			logger.Error(err, "while setting Deployment settings")
+			return fmt.Errorf("Failed to set Deployment settings: %w", err)
+		}
+	}
+	if deploymentIndex >= 0 {
+		hash, err := credentialsHash(credentialsResources...)
+		if err != nil {
+			logger.Error(err, "while computing credentials hash")
+			return fmt.Errorf("Failed to compute credentials hash: %w", err)
+		}
+		if err := r.setCredentialsHash(hash, us[deploymentIndex]); err != nil {
+			logger.Error(err, "while setting credentials hash")
+			return fmt.Errorf("Failed to set credentials hash: %w", err)
+		}
+	}
 
 	return nil
 }
@@ -458,8 +886,9 @@ func (r *BtpOperatorReconciler) addLabels(chartVer string, us ...*unstructured.U
 }
 
 func (r *BtpOperatorReconciler) setNamespace(us ...*unstructured.Unstructured) {
+	namespace := r.config().ChartNamespace
 	for _, u := range us {
-		u.SetNamespace(ChartNamespace)
+		u.SetNamespace(namespace)
 	}
 }
 
@@ -474,71 +903,171 @@ func (r *BtpOperatorReconciler) setConfigMapValues(secret *corev1.Secret, u *uns
 }
 
 func (r *BtpOperatorReconciler) setSecretValues(secret *corev1.Secret, u *unstructured.Unstructured) error {
+	certificateCredentials := usesCertificateCredentials(secret)
 	for k := range secret.Data {
+		if certificateCredentials && containsString(certificateCredentialsKeys, k) {
+			continue
+		}
 		if err := unstructured.SetNestedField(u.Object, base64.StdEncoding.EncodeToString(secret.Data[k]), "data", k); err != nil {
 			return err
 		}
 	}
+	if certificateCredentials {
+		unstructured.RemoveNestedField(u.Object, "data", clientSecretKey)
+		return unstructured.SetNestedField(u.Object, base64.StdEncoding.EncodeToString(secret.Data[certURLKey]), "data", tokenURLKey)
+	}
 	return nil
 }
 
-func (r *BtpOperatorReconciler) applyResources(ctx context.Context, us []*unstructured.Unstructured) error {
-	for _, u := range us {
-		if err := r.Patch(ctx, u, client.Apply, client.ForceOwnership, client.FieldOwner(operatorName)); err != nil {
-			return fmt.Errorf("while applying %s %s: %w", u.GetName(), u.GetKind(), err)
+func (r *BtpOperatorReconciler) setDeploymentSettings(settings *v1alpha1.DeploymentSettings, u *unstructured.Unstructured) error {
+	podSpecPath := []string{"spec", "template", "spec"}
+	if settings.Replicas != nil {
+		if err := unstructured.SetNestedField(u.Object, int64(*settings.Replicas), "spec", "replicas"); err != nil {
+			return err
+		}
+	}
+	if settings.NodeSelector != nil {
+		if err := unstructured.SetNestedStringMap(u.Object, settings.NodeSelector, append(podSpecPath, "nodeSelector")...); err != nil {
+			return err
+		}
+	}
+	if settings.Tolerations != nil {
+		tolerations := make([]interface{}, 0, len(settings.Tolerations))
+		for i := range settings.Tolerations {
+			toleration, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&settings.Tolerations[i])
+			if err != nil {
+				return err
+			}
+			tolerations = append(tolerations, toleration)
+		}
+		if err := unstructured.SetNestedSlice(u.Object, tolerations, append(podSpecPath, "tolerations")...); err != nil {
+			return err
+		}
+	}
+	if settings.Affinity != nil {
+		affinity, err := runtime.DefaultUnstructuredConverter.ToUnstructured(settings.Affinity)
+		if err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedMap(u.Object, affinity, append(podSpecPath, "affinity")...); err != nil {
+			return err
+		}
+	}
+	if settings.PriorityClassName != "" {
+		if err := unstructured.SetNestedField(u.Object, settings.PriorityClassName, append(podSpecPath, "priorityClassName")...); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return r.setManagerContainerSettings(settings, u)
 }
 
-func (r *BtpOperatorReconciler) waitForResourcesReadiness(ctx context.Context, us []*unstructured.Unstructured) error {
-	numOfResources := len(us)
-	resourcesReadinessInformer := make(chan bool, numOfResources)
-	allReadyInformer := make(chan bool)
-	for _, u := range us {
-		go r.checkResourceReadiness(ctx, u, resourcesReadinessInformer)
+func (r *BtpOperatorReconciler) setManagerContainerSettings(settings *v1alpha1.DeploymentSettings, u *unstructured.Unstructured) error {
+	containersPath := []string{"spec", "template", "spec", "containers"}
+	containers, found, err := unstructured.NestedSlice(u.Object, containersPath...)
+	if err != nil {
+		return err
 	}
-	go func(c chan bool) {
-		timeout := time.After(ReadyTimeout)
-		for i := 0; i < numOfResources; i++ {
-			select {
-			case <-resourcesReadinessInformer:
-				continue
-			case <-timeout:
-				return
+	if !found {
+		return fmt.Errorf("%s Deployment has no containers", u.GetName())
+	}
+
+	managerFound := false
+	for i, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["name"] != managerContainerName {
+			continue
+		}
+		managerFound = true
+		if settings.Resources != nil {
+			resources, err := runtime.DefaultUnstructuredConverter.ToUnstructured(settings.Resources)
+			if err != nil {
+				return err
 			}
+			container["resources"] = resources
 		}
-		allReadyInformer <- true
-	}(resourcesReadinessInformer)
-	select {
-	case <-allReadyInformer:
-		return nil
-	case <-time.After(ReadyTimeout):
-		return errors.New("resources readiness timeout reached")
+		if settings.Replicas != nil && *settings.Replicas > 1 {
+			args, _, err := unstructured.NestedStringSlice(container, "args")
+			if err != nil {
+				return err
+			}
+			if !containsString(args, leaderElectionArg) {
+				container["args"] = toInterfaceSlice(append(args, leaderElectionArg))
+			}
+		}
+		containers[i] = container
+	}
+	if !managerFound {
+		return fmt.Errorf("%s container not found in %s Deployment", managerContainerName, u.GetName())
 	}
+
+	return unstructured.SetNestedSlice(u.Object, containers, containersPath...)
 }
 
-func (r *BtpOperatorReconciler) checkResourceReadiness(ctx context.Context, u *unstructured.Unstructured, c chan<- bool) {
-	logger := log.FromContext(ctx)
-	ctxWithTimeout, cancel := context.WithTimeout(ctx, ReadyCheckInterval/2)
-	defer cancel()
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
-	var err error
-	now := time.Now()
-	got := &unstructured.Unstructured{}
-	got.SetGroupVersionKind(u.GroupVersionKind())
-	for {
-		if time.Since(now) >= ReadyTimeout {
-			logger.Error(err, fmt.Sprintf("failed to get %s %s from the cluster", u.GetName(), u.GetKind()))
-			return
+func toInterfaceSlice(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		result = append(result, v)
+	}
+	return result
+}
+
+// applyResources applies the module resources phase by phase. All resources of a phase are applied even if some of them fail,
+// and the errors are aggregated. The next phase starts only when the previous one succeeded and its resources became ready if required.
+func (r *BtpOperatorReconciler) applyResources(ctx context.Context, cr *v1alpha1.BtpOperator, us []*unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+	cfg := r.config()
+	diffs := make([]*resourceDiff, 0)
+	defer func() { r.reportApplyDiff(ctx, cr, diffs) }()
+	for _, phase := range groupIntoApplyPhases(us) {
+		logger.Info("applying module resources phase", "phase", phase.name, "resources", len(phase.resources))
+		var errs []error
+		for _, u := range phase.resources {
+			diff, err := r.applyResource(ctx, u, cfg)
+			if diff != nil {
+				diffs = append(diffs, diff)
+			}
+			if err != nil {
+				r.setResourceError(cr, u, err.Error())
+				errs = append(errs, fmt.Errorf("while applying %s %s: %w", u.GetName(), u.GetKind(), err))
+			}
+		}
+		if len(errs) > 0 {
+			return &applyPhaseError{phase: phase.name, errs: errs}
 		}
-		if err = r.Get(ctxWithTimeout, client.ObjectKey{Name: u.GetName(), Namespace: u.GetNamespace()}, got); err == nil {
-			c <- true
-			return
+		if phase.waitForReadiness {
+			logger.Info("waiting for module resources phase readiness", "phase", phase.name)
+			if err := r.waitForResourcesReadiness(ctx, cr, phase.resources); err != nil {
+				return fmt.Errorf("while waiting for %s phase readiness: %w", phase.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *BtpOperatorReconciler) applyResource(ctx context.Context, u *unstructured.Unstructured, cfg Config) (*resourceDiff, error) {
+	var diff *resourceDiff
+	if cfg.dryRunEnabled() {
+		var err error
+		if diff, err = r.diffResource(ctx, u, cfg.ProtectedFieldManagers); err != nil {
+			return nil, err
 		}
-		time.Sleep(ReadyCheckInterval)
 	}
+	if err := r.Patch(ctx, u, client.Apply, client.ForceOwnership, client.FieldOwner(operatorName)); err != nil {
+		return nil, err
+	}
+	r.recordOwnWrite(u)
+	return diff, nil
 }
 
 func (r *BtpOperatorReconciler) HandleErrorState(ctx context.Context, cr *v1alpha1.BtpOperator) error {
@@ -548,28 +1077,57 @@ func (r *BtpOperatorReconciler) HandleErrorState(ctx context.Context, cr *v1alph
 	return r.UpdateBtpOperatorStatus(ctx, cr, types.StateProcessing, Updated, "CR has been updated")
 }
 
-func (r *BtpOperatorReconciler) HandleDeletingState(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+// deletionPolicy returns the deletion policy from the CR spec, defaulting to Delete
+func deletionPolicy(cr *v1alpha1.BtpOperator) v1alpha1.DeletionPolicy {
+	if cr.Spec.DeletionPolicy == "" {
+		return v1alpha1.DeletionPolicyDelete
+	}
+	return cr.Spec.DeletionPolicy
+}
+
+func deletingReason(cr *v1alpha1.BtpOperator) Reason {
+	if cr.Status.Deprovisioning != nil && cr.Status.Deprovisioning.Phase == v1alpha1.DeprovisioningSoftDeleteStarted {
+		return SoftDeleting
+	}
+	switch deletionPolicy(cr) {
+	case v1alpha1.DeletionPolicySoftDeleteOnly:
+		return SoftDeleting
+	case v1alpha1.DeletionPolicyOrphan:
+		return Orphaning
+	default:
+		return HardDeleting
+	}
+}
+
+func (r *BtpOperatorReconciler) HandleDeletingState(ctx context.Context, cr *v1alpha1.BtpOperator) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Handling Deleting state")
+	// module resources deleted during deprovisioning are not drift
+	r.takeDrift()
 
 	if len(cr.GetFinalizers()) == 0 {
 		logger.Info("BtpOperator CR without finalizers - nothing to do, waiting for deletion")
-		return nil
+		return ctrl.Result{}, nil
 	}
 
-	if err := r.handleDeprovisioning(ctx, cr); err != nil {
+	removed, result, err := r.handleDeprovisioning(ctx, cr)
+	if err != nil {
 		logger.Error(err, "deprovisioning failed")
-		return err
+		return ctrl.Result{}, err
+	}
+	if !removed {
+		return result, nil
 	}
 	logger.Info("Deprovisioning success. Removing finalizers in CR")
 	cr.SetFinalizers([]string{})
 	if err := r.Update(ctx, cr); err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
+	deleteBtpOperatorState(cr.GetNamespace(), cr.GetName())
 	existingBtpOperators := &v1alpha1.BtpOperatorList{}
 	if err := r.List(ctx, existingBtpOperators); err != nil {
 		logger.Error(err, "unable to fetch existing BtpOperators")
-		return fmt.Errorf("while getting existing BtpOperators: %w", err)
+		return ctrl.Result{}, fmt.Errorf("while getting existing BtpOperators: %w", err)
 	}
 	for _, item := range existingBtpOperators.Items {
 		if item.GetUID() == cr.GetUID() {
@@ -581,134 +1139,207 @@ func (r *BtpOperatorReconciler) HandleDeletingState(ctx context.Context, cr *v1a
 		}
 	}
 
-	return nil
+	return ctrl.Result{}, nil
+}
+
+// handleDeprovisioning performs one bounded step of the deprovisioning and persists the reached phase in the CR status,
+// so that every reconciliation continues where the previous one stopped, also after the manager restarts.
+// It returns true once the module resources are removed, otherwise the result tells when to continue.
+func (r *BtpOperatorReconciler) handleDeprovisioning(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, ctrl.Result, error) {
+	if cr.Status.Deprovisioning == nil {
+		cr.Status.Deprovisioning = &v1alpha1.DeprovisioningStatus{}
+	}
+
+	switch phase := cr.Status.Deprovisioning.Phase; phase {
+	case "":
+		return r.startDeprovisioning(ctx, cr)
+	case v1alpha1.DeprovisioningHardDeleteStarted:
+		return r.checkHardDelete(ctx, cr)
+	case v1alpha1.DeprovisioningSoftDeleteStarted:
+		return r.continueSoftDelete(ctx, cr)
+	case v1alpha1.DeprovisioningResourcesRemoved:
+		return true, ctrl.Result{}, nil
+	default:
+		return false, ctrl.Result{}, fmt.Errorf("unknown deprovisioning phase %s", phase)
+	}
+}
+
+func (r *BtpOperatorReconciler) startDeprovisioning(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	switch deletionPolicy(cr) {
+	case v1alpha1.DeletionPolicySoftDeleteOnly:
+		logger.Info("Deletion policy SoftDeleteOnly - skipping Service Instances and Service Bindings hard delete")
+		return r.startSoftDelete(ctx, cr, "Being soft deleted with SoftDeleteOnly deletion policy")
+	case v1alpha1.DeletionPolicyOrphan:
+		logger.Info("Deletion policy Orphan - leaving Service Instances and Service Bindings untouched")
+		if err := r.handleOrphan(ctx, cr); err != nil {
+			return false, ctrl.Result{}, err
+		}
+		return r.setResourcesRemoved(ctx, cr)
+	}
+
+	start := time.Now()
+	startDeprovisioningProgress(cr, start, r.config().HardDeleteTimeout)
+	if err := r.startHardDelete(ctx); err != nil {
+		logger.Error(err, "Service Instances and Service Bindings hard delete failed")
+		observeDeprovisioning(hardDeleteMode, resultFailure, start)
+		return r.startSoftDelete(ctx, cr, "Hard delete failed, being soft deleted")
+	}
+
+	cr.Status.Deprovisioning.Phase = v1alpha1.DeprovisioningHardDeleteStarted
+	if err := r.Status().Update(ctx, cr); err != nil {
+		return false, ctrl.Result{}, err
+	}
+
+	return false, ctrl.Result{RequeueAfter: r.config().HardDeleteCheckInterval}, nil
 }
 
-func (r *BtpOperatorReconciler) handleDeprovisioning(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+// startHardDelete requests deletion of all Service Bindings and Service Instances in all namespaces
+func (r *BtpOperatorReconciler) startHardDelete(ctx context.Context) error {
 	logger := log.FromContext(ctx)
+	logger.Info("Deprovisioning BTP Operator - hard delete")
 
 	namespaces := &corev1.NamespaceList{}
 	if err := r.List(ctx, namespaces); err != nil {
 		return err
 	}
 
-	hardDeleteChannel := make(chan bool)
-	timeoutChannel := make(chan bool)
-	go r.handleHardDelete(ctx, namespaces, hardDeleteChannel, timeoutChannel)
-
-	select {
-	case hardDeleteOk := <-hardDeleteChannel:
-		if hardDeleteOk {
-			logger.Info("Service Instances and Service Bindings hard delete succeeded. Removing module resources")
-			if err := r.deleteBtpOperatorResources(ctx); err != nil {
-				logger.Error(err, "failed to remove module resources")
-				if updateStatusErr := r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, ResourceRemovalFailed, "Unable to remove installed resources"); updateStatusErr != nil {
-					logger.Error(updateStatusErr, "failed to update status")
-					return updateStatusErr
-				}
-				return err
-			}
-		} else {
-			logger.Info("Service Instances and Service Bindings hard delete failed")
-			if err := r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, SoftDeleting, "Being soft deleted"); err != nil {
-				logger.Error(err, "failed to update status")
-				return err
-			}
-			if err := r.handleSoftDelete(ctx, namespaces); err != nil {
-				logger.Error(err, "failed to soft delete")
-				return err
-			}
+	errs := make([]string, 0)
+	for _, gvk := range []schema.GroupVersionKind{bindingGvk, instanceGvk} {
+		crdExists, err := r.crdExists(ctx, gvk)
+		if err != nil {
+			logger.Error(err, "while checking CRD existence", "GVK", gvk.String())
+			errs = append(errs, err.Error())
+			continue
 		}
-	case <-time.After(HardDeleteTimeout):
-		logger.Info("hard delete timeout reached", "duration", HardDeleteTimeout)
-		timeoutChannel <- true
-		if err := r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, SoftDeleting, "Being soft deleted"); err != nil {
-			logger.Error(err, "failed to update status")
-			return err
+		if !crdExists {
+			continue
 		}
-		if err := r.handleSoftDelete(ctx, namespaces); err != nil {
-			logger.Error(err, "failed to soft delete")
-			return err
+		if err := r.hardDelete(ctx, gvk, namespaces); err != nil {
+			logger.Error(err, "while deleting resources", "GVK", gvk.String())
+			if !errors.Is(err, context.DeadlineExceeded) {
+				errs = append(errs, err.Error())
+			}
 		}
 	}
 
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+
 	return nil
 }
 
-func (r *BtpOperatorReconciler) handleHardDelete(ctx context.Context, namespaces *corev1.NamespaceList, success chan bool, timeout chan bool) {
-	defer close(success)
-	defer close(timeout)
+// checkHardDelete checks if Service Bindings and Service Instances are gone and removes the module resources,
+// falls back to soft delete after the deadline, or reports the progress and requeues
+func (r *BtpOperatorReconciler) checkHardDelete(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, ctrl.Result, error) {
 	logger := log.FromContext(ctx)
-	logger.Info("Deprovisioning BTP Operator - hard delete")
+	cfg := r.config()
 
-	errs := make([]error, 0)
+	progress := cr.Status.Deprovisioning
+	if progress.StartTime == nil || progress.SoftDeleteDeadline == nil {
+		startDeprovisioningProgress(cr, time.Now(), cfg.HardDeleteTimeout)
+	}
+	start := progress.StartTime.Time
 
-	sbCrdExists, err := r.crdExists(ctx, bindingGvk)
+	if time.Now().After(progress.SoftDeleteDeadline.Time) {
+		logger.Info("hard delete timeout reached", "duration", cfg.HardDeleteTimeout)
+		observeDeprovisioning(hardDeleteMode, resultTimeout, start)
+		return r.startSoftDelete(ctx, cr, "Hard delete timeout reached, being soft deleted")
+	}
+
+	counts, stuck, err := r.listRemainingServiceResources(ctx)
 	if err != nil {
-		logger.Error(err, "while checking CRD existence", "GVK", bindingGvk.String())
-		errs = append(errs, err)
+		logger.Error(err, "Service Instances and Service Bindings leftover resources check failed")
+		observeDeprovisioning(hardDeleteMode, resultFailure, start)
+		return r.startSoftDelete(ctx, cr, "Hard delete failed, being soft deleted")
 	}
-	if sbCrdExists {
-		if err := r.hardDelete(ctx, bindingGvk, namespaces); err != nil {
-			logger.Error(err, "while deleting Service Bindings")
-			if !errors.Is(err, context.DeadlineExceeded) {
-				errs = append(errs, err)
-			}
+	setDeprovisioningProgress(cr, counts, stuck)
+
+	if len(counts) > 0 {
+		if err := r.Status().Update(ctx, cr); err != nil {
+			return false, ctrl.Result{}, err
 		}
+		return false, ctrl.Result{RequeueAfter: cfg.HardDeleteCheckInterval}, nil
 	}
 
-	siCrdExists, err := r.crdExists(ctx, instanceGvk)
-	if err != nil {
-		logger.Error(err, "while checking CRD existence", "GVK", instanceGvk.String())
-		errs = append(errs, err)
-	}
-	if siCrdExists {
-		if err := r.hardDelete(ctx, instanceGvk, namespaces); err != nil {
-			logger.Error(err, "while deleting Service Instances")
-			if !errors.Is(err, context.DeadlineExceeded) {
-				errs = append(errs, err)
-			}
+	observeDeprovisioning(hardDeleteMode, resultSuccess, start)
+	logger.Info("Service Instances and Service Bindings hard delete succeeded. Removing module resources")
+	if err := r.deleteBtpOperatorResources(ctx, cr); err != nil {
+		logger.Error(err, "failed to remove module resources")
+		if updateStatusErr := r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, ResourceRemovalFailed, "Unable to remove installed resources"); updateStatusErr != nil {
+			logger.Error(updateStatusErr, "failed to update status")
+			return false, ctrl.Result{}, updateStatusErr
 		}
+		return false, ctrl.Result{}, err
 	}
 
-	if len(errs) > 0 {
-		success <- false
-		return
+	return r.setResourcesRemoved(ctx, cr)
+}
+
+func (r *BtpOperatorReconciler) startSoftDelete(ctx context.Context, cr *v1alpha1.BtpOperator, message string) (bool, ctrl.Result, error) {
+	cr.Status.Deprovisioning.Phase = v1alpha1.DeprovisioningSoftDeleteStarted
+	cr.Status.Deprovisioning.SoftDeleteStartTime = &metav1.Time{Time: time.Now()}
+	if err := r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, SoftDeleting, message); err != nil {
+		log.FromContext(ctx).Error(err, "failed to update status")
+		return false, ctrl.Result{}, err
 	}
 
-	var sbResourcesLeft, siResourcesLeft bool
-	for {
-		select {
-		case <-timeout:
-			return
-		default:
-		}
+	return false, ctrl.Result{Requeue: true}, nil
+}
 
-		if sbCrdExists {
-			sbResourcesLeft, err = r.resourcesExist(ctx, namespaces, bindingGvk)
-			if err != nil {
-				logger.Error(err, "ServiceBinding leftover resources check failed")
-				success <- false
-				return
+func (r *BtpOperatorReconciler) continueSoftDelete(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, ctrl.Result, error) {
+	if err := r.handleSoftDelete(ctx, cr); err != nil {
+		log.FromContext(ctx).Error(err, "failed to soft delete")
+		var reasonErr *ErrorWithReason
+		if errors.As(err, &reasonErr) && reasonErr.reason == ServiceResourcesBackupFailed {
+			if updateStatusErr := r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, reasonErr.reason, reasonErr.message); updateStatusErr != nil {
+				return false, ctrl.Result{}, updateStatusErr
 			}
+			return false, ctrl.Result{}, err
 		}
+		r.recordEvent(cr, corev1.EventTypeWarning, ResourceRemovalFailed, fmt.Sprintf("Soft delete failed: %s", err))
+		return false, ctrl.Result{}, err
+	}
 
-		if siCrdExists {
-			siResourcesLeft, err = r.resourcesExist(ctx, namespaces, instanceGvk)
-			if err != nil {
-				logger.Error(err, "ServiceInstance leftover resources check failed")
-				success <- false
-				return
-			}
-		}
+	return r.setResourcesRemoved(ctx, cr)
+}
 
-		if !sbResourcesLeft && !siResourcesLeft {
-			success <- true
-			return
-		}
+func (r *BtpOperatorReconciler) setResourcesRemoved(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, ctrl.Result, error) {
+	cr.Status.Deprovisioning.Phase = v1alpha1.DeprovisioningResourcesRemoved
+	if err := r.Status().Update(ctx, cr); err != nil {
+		return false, ctrl.Result{}, err
+	}
 
-		time.Sleep(HardDeleteCheckInterval)
+	return true, ctrl.Result{}, nil
+}
+
+// handleOrphan removes the module resources except the CRDs, so Service Instances and Service Bindings
+// stay in the cluster and in SAP BTP and are picked up again when the module is reinstalled
+func (r *BtpOperatorReconciler) handleOrphan(ctx context.Context, cr *v1alpha1.BtpOperator) (err error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Deprovisioning BTP Operator - orphan")
+
+	start := time.Now()
+	defer func() {
+		result := resultSuccess
+		if err != nil {
+			result = resultFailure
+		}
+		observeDeprovisioning(orphanMode, result, start)
+	}()
+
+	if err := r.deleteBtpOperatorResources(ctx, cr); err != nil {
+		logger.Error(err, "failed to remove module resources")
+		if updateStatusErr := r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, ResourceRemovalFailed, "Unable to remove installed resources"); updateStatusErr != nil {
+			logger.Error(updateStatusErr, "failed to update status")
+			return updateStatusErr
+		}
+		return err
 	}
+
+	return nil
 }
 
 func (r *BtpOperatorReconciler) crdExists(ctx context.Context, gvk schema.GroupVersionKind) (bool, error) {
@@ -728,7 +1359,7 @@ func (r *BtpOperatorReconciler) crdExists(ctx context.Context, gvk schema.GroupV
 func (r *BtpOperatorReconciler) hardDelete(ctx context.Context, gvk schema.GroupVersionKind, namespaces *corev1.NamespaceList) error {
 	object := &unstructured.Unstructured{}
 	object.SetGroupVersionKind(gvk)
-	deleteCtx, cancel := context.WithTimeout(ctx, HardDeleteTimeout/2)
+	deleteCtx, cancel := context.WithTimeout(ctx, r.config().HardDeleteTimeout/2)
 	defer cancel()
 
 	for _, namespace := range namespaces.Items {
@@ -740,72 +1371,88 @@ func (r *BtpOperatorReconciler) hardDelete(ctx context.Context, gvk schema.Group
 	return nil
 }
 
-func (r *BtpOperatorReconciler) resourcesExist(ctx context.Context, namespaces *corev1.NamespaceList, gvk schema.GroupVersionKind) (bool, error) {
-	anyLeft := func(namespace string, gvk schema.GroupVersionKind) (bool, error) {
-		list := &unstructured.UnstructuredList{}
-		list.SetGroupVersionKind(gvk)
-		if err := r.List(ctx, list, client.InNamespace(namespace)); err != nil {
-			if !k8serrors.IsNotFound(err) {
-				return false, err
-			}
-		}
+func (r *BtpOperatorReconciler) deleteBtpOperatorResources(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+	logger := log.FromContext(ctx)
 
-		return len(list.Items) > 0, nil
+	resourcesToDelete, err := r.getModuleResourcesToDelete(ctx, cr)
+	if err != nil {
+		return err
 	}
 
-	for _, namespace := range namespaces.Items {
-		resourcesExist, err := anyLeft(namespace.Name, gvk)
-		if err != nil {
-			return false, err
-		}
-		if resourcesExist {
-			return true, nil
-		}
+	if err = r.deleteAllOfResourcesTypes(ctx, resourcesToDelete...); err != nil {
+		logger.Error(err, "while deleting module resources")
+		return fmt.Errorf("Failed to delete module resources: %w", err)
 	}
 
-	return false, nil
+	return nil
 }
 
-func (r *BtpOperatorReconciler) deleteBtpOperatorResources(ctx context.Context) error {
+// getModuleResourcesToDelete returns module resources which types are deleted during deprovisioning
+func (r *BtpOperatorReconciler) getModuleResourcesToDelete(ctx context.Context, cr *v1alpha1.BtpOperator) ([]*unstructured.Unstructured, error) {
 	logger := log.FromContext(ctx)
 
 	logger.Info("getting module resources to delete")
-	resourcesToDeleteFromApply, err := r.createUnstructuredObjectsFromManifestsDir(r.getResourcesToApplyPath())
+	resourcesToDeleteFromApply, err := r.getModuleResources(ctx, cr, nil)
 	if err != nil {
 		logger.Error(err, "while getting objects to delete from manifests")
-		return fmt.Errorf("Failed to create deletable objects from manifests: %w", err)
+		return nil, fmt.Errorf("Failed to create deletable objects from manifests: %w", err)
 	}
-	logger.Info(fmt.Sprintf("got %d module resources to delete from \"apply\" dir", len(resourcesToDeleteFromApply)))
+	logger.Info(fmt.Sprintf("got %d current module resources to delete", len(resourcesToDeleteFromApply)))
 
 	resourcesToDeleteFromDelete, err := r.createUnstructuredObjectsFromManifestsDir(r.getResourcesToDeletePath())
 	if err != nil {
 		logger.Error(err, "while getting objects to delete from manifests")
-		return fmt.Errorf("Failed to create deletable objects from manifests: %w", err)
+		return nil, fmt.Errorf("Failed to create deletable objects from manifests: %w", err)
 	}
 	logger.Info(fmt.Sprintf("got %d module resources to delete from \"delete\" dir", len(resourcesToDeleteFromDelete)))
 
+	inventory, err := r.getInventory(ctx)
+	if err != nil {
+		logger.Error(err, "while getting applied resources inventory")
+		return nil, fmt.Errorf("Failed to get applied resources inventory: %w", err)
+	}
+	resourcesToDeleteFromInventory := make([]*unstructured.Unstructured, 0)
+	for _, gvk := range mergeGvks(inventory.oldGvks, inventory.currentGvks) {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		resourcesToDeleteFromInventory = append(resourcesToDeleteFromInventory, u)
+	}
+	logger.Info(fmt.Sprintf("got %d module resources types to delete from the inventory", len(resourcesToDeleteFromInventory)))
+
 	resourcesToDelete := make([]*unstructured.Unstructured, 0)
 	resourcesToDelete = append(resourcesToDelete, resourcesToDeleteFromApply...)
 	resourcesToDelete = append(resourcesToDelete, resourcesToDeleteFromDelete...)
+	resourcesToDelete = append(resourcesToDelete, resourcesToDeleteFromInventory...)
 
-	if err = r.deleteAllOfResourcesTypes(ctx, resourcesToDelete...); err != nil {
-		logger.Error(err, "while deleting module resources")
-		return fmt.Errorf("Failed to delete module resources: %w", err)
+	if deletionPolicy(cr) == v1alpha1.DeletionPolicyOrphan {
+		logger.Info("Deletion policy Orphan - keeping CustomResourceDefinitions")
+		resourcesToDelete = withoutKind(resourcesToDelete, crdKind)
 	}
 
-	return nil
+	return resourcesToDelete, nil
+}
+
+func withoutKind(us []*unstructured.Unstructured, kind string) []*unstructured.Unstructured {
+	filtered := make([]*unstructured.Unstructured, 0, len(us))
+	for _, u := range us {
+		if u.GetKind() != kind {
+			filtered = append(filtered, u)
+		}
+	}
+	return filtered
 }
 
 func (r *BtpOperatorReconciler) deleteAllOfResourcesTypes(ctx context.Context, resourcesToDelete ...*unstructured.Unstructured) error {
 	logger := log.FromContext(ctx)
+	namespace := r.config().ChartNamespace
 	deletedGvks := make(map[string]struct{}, 0)
 	for _, u := range resourcesToDelete {
 		if _, exists := deletedGvks[u.GroupVersionKind().String()]; exists {
 			continue
 		}
 		logger.Info(fmt.Sprintf("deleting all of %s/%s module resources in %s namespace",
-			u.GroupVersionKind().GroupVersion(), u.GetKind(), ChartNamespace))
-		if err := r.DeleteAllOf(ctx, u, client.InNamespace(ChartNamespace), managedByLabelFilter); err != nil {
+			u.GroupVersionKind().GroupVersion(), u.GetKind(), namespace))
+		if err := r.DeleteAllOf(ctx, u, client.InNamespace(namespace), managedByLabelFilter); err != nil {
 			if !(k8serrors.IsNotFound(err) || k8serrors.IsMethodNotSupported(err) || meta.IsNoMatchError(err)) {
 				return err
 			}
@@ -816,10 +1463,24 @@ func (r *BtpOperatorReconciler) deleteAllOfResourcesTypes(ctx context.Context, r
 	return nil
 }
 
-func (r *BtpOperatorReconciler) handleSoftDelete(ctx context.Context, namespaces *corev1.NamespaceList) error {
+func (r *BtpOperatorReconciler) handleSoftDelete(ctx context.Context, cr *v1alpha1.BtpOperator) (err error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Deprovisioning BTP Operator - soft delete")
 
+	start := time.Now()
+	defer func() {
+		result := resultSuccess
+		if err != nil {
+			result = resultFailure
+		}
+		observeDeprovisioning(softDeleteMode, result, start)
+	}()
+
+	if err := r.backupServiceResources(ctx, cr); err != nil {
+		logger.Error(err, "Service Instances and Service Bindings backup failed")
+		return err
+	}
+
 	logger.Info("Deleting module deployment and webhooks")
 	if err := r.preSoftDeleteCleanup(ctx); err != nil {
 		logger.Error(err, "module deployment and webhooks deletion failed")
@@ -863,7 +1524,7 @@ func (r *BtpOperatorReconciler) handleSoftDelete(ctx context.Context, namespaces
 	}
 
 	logger.Info("Deleting module resources")
-	if err := r.deleteBtpOperatorResources(ctx); err != nil {
+	if err := r.deleteBtpOperatorResources(ctx, cr); err != nil {
 		logger.Error(err, "failed to delete module resources")
 		return err
 	}
@@ -873,7 +1534,7 @@ func (r *BtpOperatorReconciler) handleSoftDelete(ctx context.Context, namespaces
 
 func (r *BtpOperatorReconciler) preSoftDeleteCleanup(ctx context.Context) error {
 	deployment := &appsv1.Deployment{}
-	if err := r.Get(ctx, client.ObjectKey{Name: DeploymentName, Namespace: ChartNamespace}, deployment); err != nil {
+	if err := r.Get(ctx, client.ObjectKey{Name: r.config().DeploymentName, Namespace: r.config().ChartNamespace}, deployment); err != nil {
 		if !k8serrors.IsNotFound(err) {
 			return err
 		}
@@ -884,7 +1545,7 @@ func (r *BtpOperatorReconciler) preSoftDeleteCleanup(ctx context.Context) error
 	}
 
 	mutatingWebhook := &admissionregistrationv1.MutatingWebhookConfiguration{}
-	if err := r.Get(ctx, client.ObjectKey{Name: mutatingWebhookName, Namespace: ChartNamespace}, mutatingWebhook); err != nil {
+	if err := r.Get(ctx, client.ObjectKey{Name: mutatingWebhookName, Namespace: r.config().ChartNamespace}, mutatingWebhook); err != nil {
 		if !k8serrors.IsNotFound(err) {
 			return err
 		}
@@ -895,7 +1556,7 @@ func (r *BtpOperatorReconciler) preSoftDeleteCleanup(ctx context.Context) error
 	}
 
 	validatingWebhook := &admissionregistrationv1.ValidatingWebhookConfiguration{}
-	if err := r.Get(ctx, client.ObjectKey{Name: validatingWebhookName, Namespace: ChartNamespace}, validatingWebhook); err != nil {
+	if err := r.Get(ctx, client.ObjectKey{Name: validatingWebhookName, Namespace: r.config().ChartNamespace}, validatingWebhook); err != nil {
 		if !k8serrors.IsNotFound(err) {
 			return err
 		}
@@ -929,7 +1590,7 @@ func (r *BtpOperatorReconciler) softDelete(ctx context.Context, gvk schema.Group
 
 		if isBinding {
 			secret := &corev1.Secret{}
-			secret.Name = item.GetName()
+			secret.Name = bindingSecretName(&item)
 			secret.Namespace = item.GetNamespace()
 			if err := r.Delete(ctx, secret); err != nil && !k8serrors.IsNotFound(err) {
 				return err
@@ -956,8 +1617,10 @@ func (r *BtpOperatorReconciler) ensureResourcesDontExist(ctx context.Context, gv
 			return err
 		}
 	} else if len(list.Items) > 0 {
+		deprovisioningRemainingResourcesGauge.WithLabelValues(gvk.Kind).Set(float64(len(list.Items)))
 		return fmt.Errorf("list returned %d records", len(list.Items))
 	}
+	deprovisioningRemainingResourcesGauge.WithLabelValues(gvk.Kind).Set(0)
 
 	return nil
 }
@@ -965,8 +1628,14 @@ func (r *BtpOperatorReconciler) ensureResourcesDontExist(ctx context.Context, gv
 func (r *BtpOperatorReconciler) HandleReadyState(ctx context.Context, cr *v1alpha1.BtpOperator) error {
 	logger := log.FromContext(ctx)
 	logger.Info("Handling Ready state")
+	previousStatus := cr.Status.DeepCopy()
 
-	secret, errWithReason := r.getAndVerifyRequiredSecret(ctx)
+	secret, errWithReason := r.getAndVerifyRequiredSecret(ctx, cr)
+	if errWithReason != nil {
+		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, errWithReason.reason, errWithReason.message)
+	}
+
+	namespaceCredentials, errWithReason := r.getNamespaceCredentialsResources(ctx, cr)
 	if errWithReason != nil {
 		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, errWithReason.reason, errWithReason.message)
 	}
@@ -975,19 +1644,29 @@ func (r *BtpOperatorReconciler) HandleReadyState(ctx context.Context, cr *v1alph
 		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, ReconcileFailed, err.Error())
 	}
 
-	if err := r.reconcileResources(ctx, secret); err != nil {
-		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, ReconcileFailed, err.Error())
+	if err := r.reconcileResources(ctx, cr, secret, namespaceCredentials); err != nil {
+		return r.UpdateBtpOperatorStatus(ctx, cr, types.StateError, reasonOf(err, ReconcileFailed), err.Error())
 	}
 
 	logger.Info("reconciliation succeeded")
-	return nil
+	return r.updateStatusIfChanged(ctx, cr, previousStatus)
+}
+
+// updateStatusIfChanged updates the status only if it differs from the previous one, so the periodic reconciliation
+// of a CR in the Ready state does not write the CR when nothing changed
+func (r *BtpOperatorReconciler) updateStatusIfChanged(ctx context.Context, cr *v1alpha1.BtpOperator, previous *v1alpha1.BtpOperatorStatus) error {
+	if equality.Semantic.DeepEqual(previous, &cr.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, cr)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *BtpOperatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Config = mgr.GetConfig()
+	r.Recorder = mgr.GetEventRecorderFor(operatorName)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.BtpOperator{},
 			builder.WithPredicates(r.watchBtpOperatorUpdatePredicate())).
 		Watches(
@@ -999,8 +1678,16 @@ func (r *BtpOperatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&source.Kind{Type: &corev1.ConfigMap{}},
 			handler.EnqueueRequestsFromMapFunc(r.reconcileConfig),
 			builder.WithPredicates(r.watchConfigPredicates()),
-		).
-		Complete(r)
+		)
+	for _, object := range managedResourceTypes {
+		b = b.Watches(
+			&source.Kind{Type: object},
+			r.managedResourceDriftHandler(),
+			builder.WithPredicates(r.watchManagedResourcePredicates()),
+		)
+	}
+
+	return b.Complete(r)
 }
 
 func (r *BtpOperatorReconciler) watchBtpOperatorUpdatePredicate() predicate.Funcs {
@@ -1016,6 +1703,9 @@ func (r *BtpOperatorReconciler) watchBtpOperatorUpdatePredicate() predicate.Func
 			if !ok {
 				return false
 			}
+			if e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() {
+				return true
+			}
 			if newBtpOperator.GetStatus().State == types.StateError && newBtpOperator.ObjectMeta.DeletionTimestamp.IsZero() {
 				return false
 			}
@@ -1054,75 +1744,66 @@ func (r *BtpOperatorReconciler) watchSecretPredicates() predicate.Funcs {
 			if !ok {
 				return false
 			}
-			return secret.Name == SecretName && secret.Namespace == ChartNamespace
+			return r.isCredentialsSecret(secret)
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			secret, ok := e.Object.(*corev1.Secret)
 			if !ok {
 				return false
 			}
-			return secret.Name == SecretName && secret.Namespace == ChartNamespace
+			return r.isCredentialsSecret(secret)
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			oldSecret, ok := e.ObjectOld.(*corev1.Secret)
 			if !ok {
 				return false
 			}
-			if oldSecret.Name == SecretName && oldSecret.Namespace == ChartNamespace {
+			return r.isCredentialsSecret(oldSecret)
+		},
+	}
+}
+
+func (r *BtpOperatorReconciler) isCredentialsSecret(secret *corev1.Secret) bool {
+	if cfg := r.config(); secret.Name == cfg.SecretName && secret.Namespace == cfg.ChartNamespace {
+		return true
+	}
+	btpOperators := &v1alpha1.BtpOperatorList{}
+	if err := r.List(context.Background(), btpOperators); err != nil {
+		return false
+	}
+	for _, cr := range btpOperators.Items {
+		if cr.Spec.CredentialsSecretRef != nil && r.credentialsSecretKey(&cr) == client.ObjectKeyFromObject(secret) {
+			return true
+		}
+		for i := range cr.Spec.NamespaceCredentials {
+			if r.secretRefKey(&cr.Spec.NamespaceCredentials[i].SecretRef) == client.ObjectKeyFromObject(secret) {
 				return true
 			}
-			return false
-		},
+		}
 	}
+	return false
 }
 
 func (r *BtpOperatorReconciler) reconcileConfig(object client.Object) []reconcile.Request {
 	logger := log.FromContext(nil, "name", object.GetName(), "namespace", object.GetNamespace())
-	cm, ok := object.(*corev1.ConfigMap)
-	if !ok {
+	if _, ok := object.(*corev1.ConfigMap); !ok {
 		return []reconcile.Request{}
 	}
-	logger.Info("reconciling config update", "config", cm.Data)
-	for k, v := range cm.Data {
-		var err error
-		switch k {
-		case "ChartNamespace":
-			ChartNamespace = v
-		case "ChartPath":
-			ChartPath = v
-		case "SecretName":
-			SecretName = v
-		case "ConfigName":
-			ConfigName = v
-		case "DeploymentName":
-			DeploymentName = v
-		case "ProcessingStateRequeueInterval":
-			ProcessingStateRequeueInterval, err = time.ParseDuration(v)
-		case "ReadyStateRequeueInterval":
-			ReadyStateRequeueInterval, err = time.ParseDuration(v)
-		case "ReadyTimeout":
-			ReadyTimeout, err = time.ParseDuration(v)
-		case "HardDeleteCheckInterval":
-			HardDeleteCheckInterval, err = time.ParseDuration(v)
-		case "HardDeleteTimeout":
-			HardDeleteTimeout, err = time.ParseDuration(v)
-		case "ResourcesPath":
-			ResourcesPath = v
-		case "ReadyCheckInterval":
-			ReadyCheckInterval, err = time.ParseDuration(v)
-		default:
-			logger.Info("unknown config update key", k, v)
-		}
-		if err != nil {
-			logger.Info("failed to parse config update", k, err)
-		}
+	cfg, rejected, err := r.loadConfig(context.Background(), client.ObjectKeyFromObject(object))
+	if err != nil {
+		logger.Error(err, "failed to reconcile config update")
+		return []reconcile.Request{}
+	}
+	logger.Info("reconciled config update", "config", cfg.values())
+	for k, reason := range rejected {
+		logger.Info("rejected config update key", k, reason)
 	}
 
 	return r.enqueueOldestBtpOperator()
 }
 
 func (r *BtpOperatorReconciler) watchConfigPredicates() predicate.Funcs {
-	nameMatches := func(o client.Object) bool { return o.GetName() == ConfigName && o.GetNamespace() == ChartNamespace }
+	nameMatches := func(o client.Object) bool { return client.ObjectKeyFromObject(o) == r.configMapKey() }
 	return predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool { return nameMatches(e.Object) },
 		DeleteFunc: func(e event.DeleteEvent) bool { return nameMatches(e.Object) },
@@ -39,9 +39,13 @@ const (
 	btpOperatorName       = "btp-operator-test"
 	defaultNamespace      = "default"
 	kymaNamespace         = "kyma-system"
+	secretName            = "sap-btp-manager"
+	configName            = "sap-btp-manager"
+	deploymentName        = "sap-btp-operator-controller-manager"
 	instanceName          = "my-service-instance"
 	bindingName           = "my-service-binding"
 	secretYamlPath        = "testdata/test-secret.yaml"
+	customSecretName      = "custom-btp-credentials"
 	priorityClassYamlPath = "testdata/test-priorityclass.yaml"
 	k8sOpsTimeout         = time.Second * 3
 	k8sOpsPollingInterval = time.Millisecond * 200
@@ -94,16 +98,16 @@ func (c *errorK8sClient) DeleteAllOf(ctx context.Context, obj client.Object, opt
 
 var _ = Describe("BTP Operator controller", Ordered, func() {
 	var cr *v1alpha1.BtpOperator
-	HardDeleteCheckInterval = 10 * time.Millisecond
-	HardDeleteTimeout = 1 * time.Second
 
 	BeforeAll(func() {
 		err := createPrereqs()
 		Expect(err).To(BeNil())
-		Expect(createChartOrResourcesCopyWithoutWebhooks(ChartPath, defaultChartPath)).To(Succeed())
-		Expect(createChartOrResourcesCopyWithoutWebhooks(ResourcesPath, defaultResourcesPath)).To(Succeed())
-		ChartPath = defaultChartPath
-		ResourcesPath = defaultResourcesPath
+		Expect(createChartOrResourcesCopyWithoutWebhooks(reconciler.config().ChartPath, defaultChartPath)).To(Succeed())
+		Expect(createChartOrResourcesCopyWithoutWebhooks(reconciler.config().ResourcesPath, defaultResourcesPath)).To(Succeed())
+		updateReconcilerConfig(func(c *Config) {
+			c.ChartPath = defaultChartPath
+			c.ResourcesPath = defaultResourcesPath
+		})
 	})
 
 	AfterAll(func() {
@@ -139,7 +143,7 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 		Describe("The required Secret exists", func() {
 			AfterEach(func() {
 				deleteSecret := &corev1.Secret{}
-				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: SecretName}, deleteSecret)).To(Succeed())
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: secretName}, deleteSecret)).To(Succeed())
 				Expect(k8sClient.Delete(ctx, deleteSecret)).To(Succeed())
 				Eventually(updateCh).Should(Receive(matchReadyCondition(types.StateError, metav1.ConditionFalse, MissingSecret)))
 			})
@@ -162,6 +166,38 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 				})
 			})
 
+			When("the CR references a custom credentials Secret with key mapping", func() {
+				It("should install chart successfully using the referenced Secret", func() {
+					Eventually(updateCh).Should(Receive(matchReadyCondition(types.StateError, metav1.ConditionFalse, MissingSecret)))
+					secret, err := createCorrectSecretFromYaml()
+					Expect(err).To(BeNil())
+					secret.Name = customSecretName
+					secret.Data["client_id"] = secret.Data["clientid"]
+					delete(secret.Data, "clientid")
+					Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+					Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: defaultNamespace, Name: btpOperatorName}, cr)).To(Succeed())
+					cr.Spec.CredentialsSecretRef = &v1alpha1.CredentialsSecretRef{
+						Name:       customSecretName,
+						Namespace:  kymaNamespace,
+						KeyMapping: map[string]string{"clientid": "client_id"},
+					}
+					Expect(k8sClient.Update(ctx, cr)).To(Succeed())
+					Eventually(updateCh).Should(Receive(matchReadyCondition(types.StateReady, metav1.ConditionTrue, ReconcileSucceeded)))
+					Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: defaultNamespace, Name: btpOperatorName}, cr)).To(Succeed())
+					credentialsCondition := meta.FindStatusCondition(toConditions(cr.Status.Conditions), CredentialsSecretType)
+					Expect(credentialsCondition).NotTo(BeNil())
+					Expect(credentialsCondition.Reason).To(Equal(string(CredentialsSecretResolved)))
+					Expect(credentialsCondition.Message).To(ContainSubstring(customSecretName))
+
+					// create the default Secret so that the AfterEach of this context can remove it
+					defaultSecret, err := createCorrectSecretFromYaml()
+					Expect(err).To(BeNil())
+					Expect(k8sClient.Create(ctx, defaultSecret)).To(Succeed())
+					Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+				})
+			})
+
 			When("the required Secret is correct", func() {
 				It("should install chart successfully", func() {
 					// requires real cluster, envtest doesn't start kube-controller-manager
@@ -172,7 +208,21 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 					Expect(k8sClient.Create(ctx, secret)).To(Succeed())
 					Eventually(updateCh).Should(Receive(matchReadyCondition(types.StateReady, metav1.ConditionTrue, ReconcileSucceeded)))
 					btpServiceOperatorDeployment := &appsv1.Deployment{}
-					Expect(k8sClient.Get(ctx, client.ObjectKey{Name: DeploymentName, Namespace: kymaNamespace}, btpServiceOperatorDeployment)).To(Succeed())
+					Expect(k8sClient.Get(ctx, client.ObjectKey{Name: deploymentName, Namespace: kymaNamespace}, btpServiceOperatorDeployment)).To(Succeed())
+
+					cr := &v1alpha1.BtpOperator{}
+					Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: defaultNamespace, Name: btpOperatorName}, cr)).To(Succeed())
+					Expect(cr.Status.Resources).To(ContainElement(v1alpha1.ResourceStatus{
+						Group:        "apps",
+						Kind:         "Deployment",
+						Namespace:    kymaNamespace,
+						Name:         deploymentName,
+						ChartVersion: btpServiceOperatorDeployment.Labels[chartVersionKey],
+						Ready:        true,
+					}))
+					for _, resource := range cr.Status.Resources {
+						Expect(resource.Ready).To(BeTrue(), "%s %s is not ready: %s", resource.Kind, resource.Name, resource.LastError)
+					}
 				})
 			})
 
@@ -182,9 +232,18 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 	Describe("Configurability", func() {
 		Context("When the ConfigMap is present", func() {
 			It("should adjust configuration settings in the operator accordingly", func() {
-				cm := initConfig(map[string]string{"ProcessingStateRequeueInterval": "10s"})
-				reconciler.reconcileConfig(cm)
-				Expect(ProcessingStateRequeueInterval).To(Equal(time.Second * 10))
+				cm := initConfig(map[string]string{"ProcessingStateRequeueInterval": "10s", "ReadyTimeout": "-1s"})
+				Expect(k8sClient.Create(ctx, cm)).To(Succeed())
+				processingStateRequeueInterval := func() time.Duration { return reconciler.config().ProcessingStateRequeueInterval }
+				Eventually(processingStateRequeueInterval).Should(Equal(time.Second * 10))
+				Expect(reconciler.config().ReadyTimeout).To(BeNumerically(">", 0))
+				Eventually(func() string {
+					_ = k8sClient.Get(ctx, client.ObjectKeyFromObject(cm), cm)
+					return cm.Annotations[rejectedConfigKeysAnnotation]
+				}).Should(ContainSubstring("ReadyTimeout"))
+
+				Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+				Eventually(processingStateRequeueInterval).Should(Equal(DefaultConfig().ProcessingStateRequeueInterval))
 			})
 		})
 	})
@@ -200,7 +259,7 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 			Expect(k8sClient.Create(ctx, cr)).To(Succeed())
 			Eventually(updateCh).Should(Receive(matchState(types.StateReady)))
 			btpServiceOperatorDeployment := &appsv1.Deployment{}
-			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: DeploymentName, Namespace: kymaNamespace}, btpServiceOperatorDeployment)).Should(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: deploymentName, Namespace: kymaNamespace}, btpServiceOperatorDeployment)).Should(Succeed())
 
 			siUnstructured = createResource(instanceGvk, kymaNamespace, instanceName)
 			ensureResourceExists(instanceGvk)
@@ -211,7 +270,7 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 
 		AfterEach(func() {
 			deleteSecret := &corev1.Secret{}
-			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: SecretName}, deleteSecret)).To(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: secretName}, deleteSecret)).To(Succeed())
 			Expect(k8sClient.Delete(ctx, deleteSecret)).To(Succeed())
 		})
 
@@ -267,6 +326,8 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 
 			manifestHandler = &manifest.Handler{Scheme: k8sManager.GetScheme()}
 			actualWorkqueueSize = func() int { return reconciler.workqueueSize }
+			// update scenarios modify pre-rendered module resources
+			updateReconcilerConfig(func(c *Config) { c.RenderChart = false })
 		})
 
 		AfterAll(func() {
@@ -275,8 +336,11 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 			err = os.RemoveAll(resourcesUpdatePath)
 			Expect(err).To(BeNil())
 
-			ChartPath = defaultChartPath
-			ResourcesPath = defaultResourcesPath
+			updateReconcilerConfig(func(c *Config) {
+				c.ChartPath = defaultChartPath
+				c.ResourcesPath = defaultResourcesPath
+				c.RenderChart = true
+			})
 		})
 
 		BeforeEach(func() {
@@ -285,7 +349,7 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 			Eventually(updateCh).Should(Receive(matchState(types.StateProcessing)))
 			Eventually(updateCh).Should(Receive(matchState(types.StateReady)))
 
-			initChartVersion, err = ymlutils.ExtractStringValueFromYamlForGivenKey(fmt.Sprintf("%s/Chart.yaml", ChartPath), "version")
+			initChartVersion, err = ymlutils.ExtractStringValueFromYamlForGivenKey(fmt.Sprintf("%s/Chart.yaml", reconciler.config().ChartPath), "version")
 			Expect(err).To(BeNil())
 			_ = initChartVersion
 
@@ -297,10 +361,12 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 			initResourcesNum, err = countResourcesForGivenChartVer(gvks, initChartVersion)
 			Expect(err).To(BeNil())
 
-			copyDirRecursively(ChartPath, chartUpdatePath)
-			copyDirRecursively(ResourcesPath, resourcesUpdatePath)
-			ChartPath = chartUpdatePath
-			ResourcesPath = resourcesUpdatePath
+			copyDirRecursively(reconciler.config().ChartPath, chartUpdatePath)
+			copyDirRecursively(reconciler.config().ResourcesPath, resourcesUpdatePath)
+			updateReconcilerConfig(func(c *Config) {
+				c.ChartPath = chartUpdatePath
+				c.ResourcesPath = resourcesUpdatePath
+			})
 		})
 
 		AfterEach(func() {
@@ -316,8 +382,10 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 			err = os.RemoveAll(resourcesUpdatePath)
 			Expect(err).To(BeNil())
 
-			ChartPath = defaultChartPath
-			ResourcesPath = defaultResourcesPath
+			updateReconcilerConfig(func(c *Config) {
+				c.ChartPath = defaultChartPath
+				c.ResourcesPath = defaultResourcesPath
+			})
 		})
 
 		When("update all resources names and bump chart version", Label("test-update"), func() {
@@ -432,6 +500,50 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 			})
 		})
 
+		When("remove some manifests without listing them in the delete directory and bump chart version", Label("test-update"), func() {
+			It("resources without manifests should be pruned based on the applied resources inventory", func() {
+				allManifests, err := manifestHandler.GetManifestsFromDir(getApplyPath())
+				Expect(err).To(BeNil())
+				err = moveOrCopyNFilesFromDirToDir(len(allManifests), true, getApplyPath(), getTempPath())
+				Expect(err).To(BeNil())
+
+				remainingManifestsNum := 4
+				err = moveOrCopyNFilesFromDirToDir(remainingManifestsNum, true, getTempPath(), getApplyPath())
+				Expect(err).To(BeNil())
+
+				expectedDeleteObjs, err := manifestHandler.CollectObjectsFromDir(getTempPath())
+				Expect(err).To(BeNil())
+				unexpectedUns, err := manifestHandler.ObjectsToUnstructured(expectedDeleteObjs)
+				Expect(err).To(BeNil())
+
+				expectedApplyObjs, err := manifestHandler.CollectObjectsFromDir(getApplyPath())
+				Expect(err).To(BeNil())
+				expectedUns, err := manifestHandler.ObjectsToUnstructured(expectedApplyObjs)
+				Expect(err).To(BeNil())
+
+				err = ymlutils.UpdateChartVersion(chartUpdatePath, newChartVersion)
+				Expect(err).To(BeNil())
+
+				Eventually(actualWorkqueueSize).WithTimeout(time.Second * 5).WithPolling(time.Millisecond * 100).Should(Equal(0))
+				_, err = reconciler.Reconcile(ctx, controllerruntime.Request{NamespacedName: apimachienerytypes.NamespacedName{
+					Namespace: cr.Namespace,
+					Name:      cr.Name,
+				}})
+				Expect(err).To(BeNil())
+
+				actualNumOfOldResources, err := countResourcesForGivenChartVer(gvks, initChartVersion)
+				Expect(err).To(BeNil())
+				Expect(actualNumOfOldResources).To(Equal(0))
+				assertResourcesExistence(expectedUns...)
+				assertResourcesRemoval(unexpectedUns...)
+
+				inventory := &corev1.ConfigMap{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: kymaNamespace, Name: btpManagerConfigMap}, inventory)).To(Succeed())
+				Expect(inventory.Data).To(HaveKeyWithValue(currentCharVersionKey, newChartVersion))
+				Expect(inventory.Data).To(HaveKeyWithValue(oldChartVersionKey, initChartVersion))
+			})
+		})
+
 		When("bump chart version only", Label("test-update"), func() {
 			It("resources should stay and receive new chart version", func() {
 				err = ymlutils.UpdateChartVersion(chartUpdatePath, newChartVersion)
@@ -456,11 +568,11 @@ var _ = Describe("BTP Operator controller", Ordered, func() {
 })
 
 func getApplyPath() string {
-	return fmt.Sprintf("%s%capply", ResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%capply", reconciler.config().ResourcesPath, os.PathSeparator)
 }
 
 func getDeletePath() string {
-	return fmt.Sprintf("%s%cdelete", ResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%cdelete", reconciler.config().ResourcesPath, os.PathSeparator)
 }
 
 func getToDeleteYamlPath() string {
@@ -468,7 +580,7 @@ func getToDeleteYamlPath() string {
 }
 
 func getTempPath() string {
-	return fmt.Sprintf("%s%ctemp", ResourcesPath, os.PathSeparator)
+	return fmt.Sprintf("%s%ctemp", reconciler.config().ResourcesPath, os.PathSeparator)
 }
 
 func assertResourcesExistence(uns ...*unstructured.Unstructured) {
@@ -609,6 +721,14 @@ func getCurrentCrStatus() types.Status {
 	return cr.GetStatus()
 }
 
+func toConditions(conditions []*metav1.Condition) []metav1.Condition {
+	result := make([]metav1.Condition, 0, len(conditions))
+	for _, c := range conditions {
+		result = append(result, *c)
+	}
+	return result
+}
+
 func isCrNotFound() bool {
 	cr := &v1alpha1.BtpOperator{}
 	err := k8sClient.Get(ctx, client.ObjectKey{Namespace: defaultNamespace, Name: btpOperatorName}, cr)
@@ -631,8 +751,8 @@ func createBtpOperator() *v1alpha1.BtpOperator {
 func initConfig(data map[string]string) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ConfigName,
-			Namespace: ChartNamespace,
+			Name:      configName,
+			Namespace: kymaNamespace,
 		},
 		Data: data,
 	}
@@ -839,13 +959,13 @@ func checkIfNoServiceExists(kind string) {
 
 func checkIfNoBindingSecretExists() {
 	secret := &corev1.Secret{}
-	err := k8sClient.Get(ctx, client.ObjectKey{Name: bindingName, Namespace: ChartNamespace}, secret)
+	err := k8sClient.Get(ctx, client.ObjectKey{Name: bindingName, Namespace: kymaNamespace}, secret)
 	Expect(*secret).To(BeEquivalentTo(corev1.Secret{}))
 	Expect(k8serrors.IsNotFound(err)).To(BeTrue())
 }
 
 func checkIfNoBtpResourceExists() {
-	gvks, err := ymlutils.GatherChartGvks(ChartPath)
+	gvks, err := ymlutils.GatherChartGvks(reconciler.config().ChartPath)
 	Expect(err).To(BeNil())
 
 	found := false
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetChartValues(t *testing.T) {
+	r := NewBtpOperatorReconciler(fake.NewClientBuilder().Build(), clientgoscheme.Scheme)
+	useModuleChart(r)
+	cr := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{ChartValues: &apiextensionsv1.JSON{
+		Raw: []byte(`{"manager": {"replica_count": 3, "secret": {"clientid": "from-spec"}}, "cluster": {"id": "from-spec"}}`),
+	}}}
+	secret := &corev1.Secret{Data: map[string][]byte{"clientid": []byte("from-secret"), "cluster_id": []byte("cluster")}}
+
+	values, err := r.getChartValues(cr, secret)
+	require.NoError(t, err)
+
+	replicas, _, _ := unstructured.NestedFieldNoCopy(values, "manager", "replica_count")
+	assert.EqualValues(t, 3, replicas, "spec values should override chart overrides")
+	clientID, _, _ := unstructured.NestedString(values, "manager", "secret", "clientid")
+	assert.Equal(t, "from-secret", clientID, "credentials should override spec values")
+	clusterID, _, _ := unstructured.NestedString(values, "cluster", "id")
+	assert.Equal(t, "cluster", clusterID)
+	istioInjection, _, _ := unstructured.NestedString(values, "manager", "annotations", "sidecar.istio.io/inject")
+	assert.Equal(t, "false", istioInjection, "chart overrides should be kept")
+	certificates, _, _ := unstructured.NestedMap(values, "manager", "certificates")
+	assert.Empty(t, certificates, "disabled certificate modes should be dropped")
+}
+
+func TestReuseWebhookCertificates(t *testing.T) {
+	ctx := context.Background()
+	existingCrt, existingKey, existingCa := []byte("existing-crt"), []byte("existing-key"), []byte("existing-ca")
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: webhookServerCertSecret, Namespace: kymaNamespace},
+			Data:       map[string][]byte{corev1.TLSCertKey: existingCrt, corev1.TLSPrivateKeyKey: existingKey},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: mutatingWebhookName},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				{Name: "webhook", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: existingCa}},
+			},
+		},
+	).Build()
+	r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+	useModuleChart(r)
+	cr := &v1alpha1.BtpOperator{}
+
+	us, err := r.getModuleResources(ctx, cr, nil)
+	require.NoError(t, err)
+	require.NoError(t, r.reuseWebhookCertificates(ctx, cr, us))
+
+	webhooksFound := 0
+	for _, u := range us {
+		switch u.GetKind() {
+		case secretKind:
+			if u.GetName() != webhookServerCertSecret && u.GetName() != btpServiceOperatorTlsSecret {
+				continue
+			}
+			crt, _, _ := unstructured.NestedString(u.Object, "data", corev1.TLSCertKey)
+			key, _, _ := unstructured.NestedString(u.Object, "data", corev1.TLSPrivateKeyKey)
+			assert.Equal(t, base64.StdEncoding.EncodeToString(existingCrt), crt, u.GetName())
+			assert.Equal(t, base64.StdEncoding.EncodeToString(existingKey), key, u.GetName())
+		case "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration":
+			webhooks, _, _ := unstructured.NestedSlice(u.Object, "webhooks")
+			for _, w := range webhooks {
+				caBundle, _, _ := unstructured.NestedString(w.(map[string]interface{}), "clientConfig", "caBundle")
+				assert.Equal(t, base64.StdEncoding.EncodeToString(existingCa), caBundle)
+				webhooksFound++
+			}
+		}
+	}
+	assert.NotZero(t, webhooksFound)
+}
+
+func TestGetModuleResourcesFallsBackToPrerenderedResources(t *testing.T) {
+	r := NewBtpOperatorReconciler(fake.NewClientBuilder().Build(), clientgoscheme.Scheme)
+	useModuleChart(r)
+	cfg := r.config()
+	cfg.ChartPath = t.TempDir()
+	r.SetConfig(cfg)
+
+	us, err := r.getModuleResources(context.Background(), &v1alpha1.BtpOperator{}, nil)
+	require.NoError(t, err)
+
+	expected, err := r.createUnstructuredObjectsFromManifestsDir(r.getResourcesToApplyPath())
+	require.NoError(t, err)
+	assert.Equal(t, expected, us)
+}
+
+func useModuleChart(r *BtpOperatorReconciler) {
+	cfg := r.config()
+	cfg.ChartPath = "../module-chart/chart"
+	cfg.ChartOverridesPath = "../module-chart/overrides.yaml"
+	cfg.ResourcesPath = "../module-resources"
+	cfg.RenderChart = true
+	r.SetConfig(cfg)
+}
@@ -20,6 +20,9 @@ const (
 	HardDeleting                       Reason = "HardDeleting"
 	ResourceRemovalFailed              Reason = "ResourceRemovalFailed"
 	SoftDeleting                       Reason = "SoftDeleting"
+	Orphaning                          Reason = "Orphaning"
+	DeletionBlocked                    Reason = "DeletionBlocked"
+	ServiceResourcesBackupFailed       Reason = "ServiceResourcesBackupFailed"
 	Updated                            Reason = "Updated"
 	UpdateCheck                        Reason = "UpdateCheck"
 	UpdateCheckSucceeded               Reason = "UpdateCheckSucceeded"
@@ -34,7 +37,19 @@ const (
 	PreparingModuleResourcesFailed     Reason = "PreparingModuleResourcesFailed"
 	ProvisioningFailed                 Reason = "ProvisioningFailed"
 	UpdateFailed                       Reason = "UpdateFailed"
+	InvalidCredentialsSecretRef        Reason = "InvalidCredentialsSecretRef"
+	CredentialsSecretResolved          Reason = "CredentialsSecretResolved"
+	InvalidCredentials                 Reason = "InvalidCredentials"
+	ServiceManagerUnreachable          Reason = "ServiceManagerUnreachable"
+	CertificateValid                   Reason = "CertificateValid"
+	CertificateExpiresSoon             Reason = "CertificateExpiresSoon"
+	InvalidNamespaceCredentials        Reason = "InvalidNamespaceCredentials"
+	FieldOwnershipConflict             Reason = "FieldOwnershipConflict"
+	UpgradeRolledBack                  Reason = "UpgradeRolledBack"
+	IncompatibleCRDUpgrade             Reason = "IncompatibleCRDUpgrade"
 	ReadyType                                 = "Ready"
+	CredentialsSecretType                     = "CredentialsSecret"
+	CredentialsCertificateType                = "CredentialsCertificate"
 )
 
 type TypeAndStatus struct {
@@ -52,6 +67,21 @@ var NotReady = TypeAndStatus{
 	Type:   ReadyType,
 }
 
+var CredentialsSecretFound = TypeAndStatus{
+	Status: metav1.ConditionTrue,
+	Type:   CredentialsSecretType,
+}
+
+var CredentialsCertificateValid = TypeAndStatus{
+	Status: metav1.ConditionTrue,
+	Type:   CredentialsCertificateType,
+}
+
+var CredentialsCertificateExpiring = TypeAndStatus{
+	Status: metav1.ConditionFalse,
+	Type:   CredentialsCertificateType,
+}
+
 var Reasons = map[Reason]TypeAndStatus{
 	ReconcileSucceeded:                 Ready,
 	UpdateDone:                         Ready,
@@ -68,6 +98,9 @@ var Reasons = map[Reason]TypeAndStatus{
 	HardDeleting:                       NotReady,
 	ResourceRemovalFailed:              NotReady,
 	SoftDeleting:                       NotReady,
+	Orphaning:                          NotReady,
+	DeletionBlocked:                    NotReady,
+	ServiceResourcesBackupFailed:       NotReady,
 	UpdateCheck:                        NotReady,
 	InconsistentChart:                  NotReady,
 	PreparingInstallInfoFailed:         NotReady,
@@ -79,6 +112,16 @@ var Reasons = map[Reason]TypeAndStatus{
 	PreparingModuleResourcesFailed:     NotReady,
 	ProvisioningFailed:                 NotReady,
 	UpdateFailed:                       NotReady,
+	InvalidCredentialsSecretRef:        NotReady,
+	InvalidCredentials:                 NotReady,
+	ServiceManagerUnreachable:          NotReady,
+	InvalidNamespaceCredentials:        NotReady,
+	FieldOwnershipConflict:             NotReady,
+	UpgradeRolledBack:                  NotReady,
+	IncompatibleCRDUpgrade:             NotReady,
+	CredentialsSecretResolved:          CredentialsSecretFound,
+	CertificateValid:                   CredentialsCertificateValid,
+	CertificateExpiresSoon:             CredentialsCertificateExpiring,
 }
 
 func ConditionFromExistingReason(reason Reason, message string) *metav1.Condition {
@@ -114,3 +157,25 @@ func SetStatusCondition(conditions *[]*metav1.Condition, newCondition metav1.Con
 		(*conditions)[conditionsCnt] = &conditionsArray[conditionsCnt]
 	}
 }
+
+// FindStatusCondition finds the conditionType in conditions, the counterpart of SetStatusCondition
+func FindStatusCondition(conditions []*metav1.Condition, conditionType string) *metav1.Condition {
+	for _, condition := range conditions {
+		if condition != nil && condition.Type == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+// RemoveStatusCondition removes the conditionType from conditions, the counterpart of SetStatusCondition
+func RemoveStatusCondition(conditions *[]*metav1.Condition, conditionType string) {
+	filtered := make([]*metav1.Condition, 0, len(*conditions))
+	for _, condition := range *conditions {
+		if condition != nil && condition.Type == conditionType {
+			continue
+		}
+		filtered = append(filtered, condition)
+	}
+	*conditions = filtered
+}
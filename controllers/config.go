@@ -0,0 +1,360 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	effectiveConfigAnnotation    = "operator.kyma-project.io/effective-config"
+	rejectedConfigKeysAnnotation = "operator.kyma-project.io/rejected-config-keys"
+
+	// maxFieldManagerLength is the limit of the field manager name enforced by the API server
+	maxFieldManagerLength = 128
+)
+
+// Config holds the settings of the reconciler. The base configuration is set from the flags, and the keys of the
+// ConfigMap named ConfigName in the ChartNamespace override it. Every key of the ConfigMap is named after the field.
+type Config struct {
+	ChartNamespace                 string
+	SecretName                     string
+	ConfigName                     string
+	DeploymentName                 string
+	ProcessingStateRequeueInterval time.Duration
+	ReadyStateRequeueInterval      time.Duration
+	ReadyTimeout                   time.Duration
+	ReadyCheckInterval             time.Duration
+	HardDeleteTimeout              time.Duration
+	HardDeleteCheckInterval        time.Duration
+	DeletionBlockedRequeueInterval time.Duration
+	ChartPath                      string
+	ResourcesPath                  string
+	ChartOverridesPath             string
+	RenderChart                    bool
+	CredentialsCheck               bool
+	CredentialsCheckTimeout        time.Duration
+	CertificateExpiryWarningPeriod time.Duration
+	ApplyDiff                      bool
+	ProtectedFieldManagers         []string
+}
+
+// DefaultConfig returns the configuration used when neither the flags nor the ConfigMap set a value
+func DefaultConfig() Config {
+	return Config{
+		ChartNamespace:                 "kyma-system",
+		SecretName:                     "sap-btp-manager",
+		ConfigName:                     "sap-btp-manager",
+		DeploymentName:                 "sap-btp-operator-controller-manager",
+		ProcessingStateRequeueInterval: time.Minute * 5,
+		ReadyStateRequeueInterval:      time.Minute * 15,
+		ReadyTimeout:                   time.Minute * 1,
+		ReadyCheckInterval:             time.Second * 2,
+		HardDeleteTimeout:              time.Minute * 20,
+		HardDeleteCheckInterval:        time.Second * 10,
+		DeletionBlockedRequeueInterval: time.Minute * 1,
+		ChartPath:                      "./module-chart/chart",
+		ResourcesPath:                  "./module-resources",
+		ChartOverridesPath:             "./module-chart/overrides.yaml",
+		RenderChart:                    true,
+		CredentialsCheck:               false,
+		CredentialsCheckTimeout:        time.Second * 10,
+		CertificateExpiryWarningPeriod: time.Hour * 24 * 30,
+		ApplyDiff:                      false,
+	}
+}
+
+// Validate checks every value of the configuration the same way as the values of the ConfigMap
+func (c Config) Validate() error {
+	var errs []string
+	for key, value := range c.values() {
+		var validated Config
+		if err := validated.set(key, value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", key, err))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// withOverrides returns the configuration with the values of the ConfigMap data applied. Keys which are unknown,
+// cannot be parsed or have an invalid value are skipped and returned together with the reason.
+func (c Config) withOverrides(data map[string]string) (Config, map[string]string) {
+	rejected := make(map[string]string)
+	for key, value := range data {
+		if key == "ConfigName" {
+			rejected[key] = "the ConfigMap cannot rename itself"
+			continue
+		}
+		if err := c.set(key, value); err != nil {
+			rejected[key] = err.Error()
+		}
+	}
+	return c, rejected
+}
+
+// set parses and validates the value of the key, the configuration is changed only if the value is valid
+func (c *Config) set(key, value string) error {
+	var err error
+	switch key {
+	case "ChartNamespace":
+		err = validateName(value, validation.IsDNS1123Label)
+		if err == nil {
+			c.ChartNamespace = value
+		}
+	case "SecretName":
+		err = validateName(value, validation.IsDNS1123Subdomain)
+		if err == nil {
+			c.SecretName = value
+		}
+	case "ConfigName":
+		err = validateName(value, validation.IsDNS1123Subdomain)
+		if err == nil {
+			c.ConfigName = value
+		}
+	case "DeploymentName":
+		err = validateName(value, validation.IsDNS1123Subdomain)
+		if err == nil {
+			c.DeploymentName = value
+		}
+	case "ProcessingStateRequeueInterval":
+		err = setPositiveDuration(&c.ProcessingStateRequeueInterval, value)
+	case "ReadyStateRequeueInterval":
+		err = setPositiveDuration(&c.ReadyStateRequeueInterval, value)
+	case "ReadyTimeout":
+		err = setPositiveDuration(&c.ReadyTimeout, value)
+	case "ReadyCheckInterval":
+		err = setPositiveDuration(&c.ReadyCheckInterval, value)
+	case "HardDeleteTimeout":
+		err = setPositiveDuration(&c.HardDeleteTimeout, value)
+	case "HardDeleteCheckInterval":
+		err = setPositiveDuration(&c.HardDeleteCheckInterval, value)
+	case "DeletionBlockedRequeueInterval":
+		err = setPositiveDuration(&c.DeletionBlockedRequeueInterval, value)
+	case "ChartPath":
+		err = validatePath(value, true)
+		if err == nil {
+			c.ChartPath = value
+		}
+	case "ResourcesPath":
+		err = validatePath(value, true)
+		if err == nil {
+			c.ResourcesPath = value
+		}
+	case "ChartOverridesPath":
+		if value != "" {
+			err = validatePath(value, false)
+		}
+		if err == nil {
+			c.ChartOverridesPath = value
+		}
+	case "RenderChart":
+		err = setBool(&c.RenderChart, value)
+	case "CredentialsCheck":
+		err = setBool(&c.CredentialsCheck, value)
+	case "CredentialsCheckTimeout":
+		err = setPositiveDuration(&c.CredentialsCheckTimeout, value)
+	case "CertificateExpiryWarningPeriod":
+		err = setPositiveDuration(&c.CertificateExpiryWarningPeriod, value)
+	case "ApplyDiff":
+		err = setBool(&c.ApplyDiff, value)
+	case "ProtectedFieldManagers":
+		err = setFieldManagers(&c.ProtectedFieldManagers, value)
+	default:
+		err = errors.New("unknown key")
+	}
+	return err
+}
+
+// values returns the configuration in the format of the ConfigMap data
+func (c Config) values() map[string]string {
+	return map[string]string{
+		"ChartNamespace":                 c.ChartNamespace,
+		"SecretName":                     c.SecretName,
+		"ConfigName":                     c.ConfigName,
+		"DeploymentName":                 c.DeploymentName,
+		"ProcessingStateRequeueInterval": c.ProcessingStateRequeueInterval.String(),
+		"ReadyStateRequeueInterval":      c.ReadyStateRequeueInterval.String(),
+		"ReadyTimeout":                   c.ReadyTimeout.String(),
+		"ReadyCheckInterval":             c.ReadyCheckInterval.String(),
+		"HardDeleteTimeout":              c.HardDeleteTimeout.String(),
+		"HardDeleteCheckInterval":        c.HardDeleteCheckInterval.String(),
+		"DeletionBlockedRequeueInterval": c.DeletionBlockedRequeueInterval.String(),
+		"ChartPath":                      c.ChartPath,
+		"ResourcesPath":                  c.ResourcesPath,
+		"ChartOverridesPath":             c.ChartOverridesPath,
+		"RenderChart":                    strconv.FormatBool(c.RenderChart),
+		"CredentialsCheck":               strconv.FormatBool(c.CredentialsCheck),
+		"CredentialsCheckTimeout":        c.CredentialsCheckTimeout.String(),
+		"CertificateExpiryWarningPeriod": c.CertificateExpiryWarningPeriod.String(),
+		"ApplyDiff":                      strconv.FormatBool(c.ApplyDiff),
+		"ProtectedFieldManagers":         strings.Join(c.ProtectedFieldManagers, ","),
+	}
+}
+
+func validateName(value string, validate func(string) []string) error {
+	if errs := validate(value); len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func setPositiveDuration(field *time.Duration, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return errors.New("duration must be positive")
+	}
+	*field = d
+	return nil
+}
+
+func setBool(field *bool, value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*field = b
+	return nil
+}
+
+func setFieldManagers(field *[]string, value string) error {
+	managers, err := ParseFieldManagers(value)
+	if err != nil {
+		return err
+	}
+	*field = managers
+	return nil
+}
+
+// ParseFieldManagers parses a comma-separated list of field manager names, empty entries are skipped
+func ParseFieldManagers(value string) ([]string, error) {
+	var managers []string
+	for _, manager := range strings.Split(value, ",") {
+		manager = strings.TrimSpace(manager)
+		if manager == "" {
+			continue
+		}
+		if len(manager) > maxFieldManagerLength {
+			return nil, fmt.Errorf("field manager %q is longer than %d characters", manager, maxFieldManagerLength)
+		}
+		managers = append(managers, manager)
+	}
+	return managers, nil
+}
+
+func validatePath(path string, dir bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if dir && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
+	}
+	if !dir && info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+// config returns the current configuration, it is safe to call concurrently with the ConfigMap updates
+func (r *BtpOperatorReconciler) config() Config {
+	r.configLock.RLock()
+	defer r.configLock.RUnlock()
+	if r.currentConfig == nil {
+		return DefaultConfig()
+	}
+	return *r.currentConfig
+}
+
+// SetConfig sets the base configuration which is overridden by the ConfigMap, and makes it the current one
+func (r *BtpOperatorReconciler) SetConfig(cfg Config) {
+	r.configLock.Lock()
+	defer r.configLock.Unlock()
+	base, current := cfg, cfg
+	r.baseConfig, r.currentConfig = &base, &current
+}
+
+// configMapKey returns the key of the ConfigMap which overrides the base configuration
+func (r *BtpOperatorReconciler) configMapKey() client.ObjectKey {
+	r.configLock.RLock()
+	defer r.configLock.RUnlock()
+	base := DefaultConfig()
+	if r.baseConfig != nil {
+		base = *r.baseConfig
+	}
+	return client.ObjectKey{Namespace: base.ChartNamespace, Name: base.ConfigName}
+}
+
+// loadConfig applies the ConfigMap over the base configuration and swaps the current configuration with the result.
+// Rejected keys keep the base value. A missing ConfigMap restores the base configuration.
+func (r *BtpOperatorReconciler) loadConfig(ctx context.Context, key client.ObjectKey) (Config, map[string]string, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, key, cm); err != nil && !k8serrors.IsNotFound(err) {
+		return Config{}, nil, fmt.Errorf("while getting %s/%s ConfigMap: %w", key.Namespace, key.Name, err)
+	}
+
+	r.configLock.Lock()
+	base := DefaultConfig()
+	if r.baseConfig != nil {
+		base = *r.baseConfig
+	}
+	cfg, rejected := base.withOverrides(cm.Data)
+	r.currentConfig = &cfg
+	r.configLock.Unlock()
+
+	if cm.Name != "" {
+		if err := r.annotateConfig(ctx, cm, cfg, rejected); err != nil {
+			return cfg, rejected, err
+		}
+	}
+	return cfg, rejected, nil
+}
+
+// annotateConfig reports the effective configuration and the rejected keys in the annotations of the ConfigMap
+func (r *BtpOperatorReconciler) annotateConfig(ctx context.Context, cm *corev1.ConfigMap, cfg Config, rejected map[string]string) error {
+	effective, err := json.Marshal(cfg.values())
+	if err != nil {
+		return fmt.Errorf("while marshalling effective config: %w", err)
+	}
+	annotated := cm.DeepCopy()
+	if annotated.Annotations == nil {
+		annotated.Annotations = make(map[string]string)
+	}
+	annotated.Annotations[effectiveConfigAnnotation] = string(effective)
+	delete(annotated.Annotations, rejectedConfigKeysAnnotation)
+	if len(rejected) > 0 {
+		rejectedKeys, err := json.Marshal(rejected)
+		if err != nil {
+			return fmt.Errorf("while marshalling rejected config keys: %w", err)
+		}
+		annotated.Annotations[rejectedConfigKeysAnnotation] = string(rejectedKeys)
+	}
+	if annotated.Annotations[effectiveConfigAnnotation] == cm.Annotations[effectiveConfigAnnotation] &&
+		annotated.Annotations[rejectedConfigKeysAnnotation] == cm.Annotations[rejectedConfigKeysAnnotation] {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("reporting effective config", "rejected", rejected)
+	if err := r.Patch(ctx, annotated, client.MergeFrom(cm)); err != nil {
+		return fmt.Errorf("while annotating %s/%s ConfigMap: %w", cm.Namespace, cm.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigWithOverrides(t *testing.T) {
+	// given
+	base := DefaultConfig()
+	chartPath := t.TempDir()
+
+	// when
+	cfg, rejected := base.withOverrides(map[string]string{
+		"ChartNamespace":            "custom-system",
+		"ChartPath":                 chartPath,
+		"ChartOverridesPath":        "",
+		"HardDeleteTimeout":         "1h",
+		"RenderChart":               "false",
+		"ReadyTimeout":              "-1s",
+		"ReadyCheckInterval":        "often",
+		"ResourcesPath":             "./not-existing",
+		"DeploymentName":            "Invalid_Name",
+		"ConfigName":                "other",
+		"ReadyStateRequeueInterval": "0s",
+		"ProtectedFieldManagers":    " kubectl-edit, ,argocd ",
+		"Unknown":                   "value",
+	})
+
+	// then
+	assert.Equal(t, "custom-system", cfg.ChartNamespace)
+	assert.Equal(t, chartPath, cfg.ChartPath)
+	assert.Empty(t, cfg.ChartOverridesPath)
+	assert.Equal(t, time.Hour, cfg.HardDeleteTimeout)
+	assert.False(t, cfg.RenderChart)
+	assert.Equal(t, base.ReadyTimeout, cfg.ReadyTimeout)
+	assert.Equal(t, base.ReadyCheckInterval, cfg.ReadyCheckInterval)
+	assert.Equal(t, base.ResourcesPath, cfg.ResourcesPath)
+	assert.Equal(t, base.DeploymentName, cfg.DeploymentName)
+	assert.Equal(t, base.ConfigName, cfg.ConfigName)
+	assert.Equal(t, base.ReadyStateRequeueInterval, cfg.ReadyStateRequeueInterval)
+	assert.Equal(t, []string{"kubectl-edit", "argocd"}, cfg.ProtectedFieldManagers)
+	assert.ElementsMatch(t, []string{"ReadyTimeout", "ReadyCheckInterval", "ResourcesPath", "DeploymentName", "ConfigName", "ReadyStateRequeueInterval", "Unknown"}, keys(rejected))
+	assert.Equal(t, "duration must be positive", rejected["ReadyTimeout"])
+	assert.Equal(t, "unknown key", rejected["Unknown"])
+	assert.Equal(t, DefaultConfig(), base, "base config should not be changed")
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ChartPath, cfg.ResourcesPath, cfg.ChartOverridesPath = t.TempDir(), t.TempDir(), ""
+	assert.NoError(t, cfg.Validate())
+
+	cfg.HardDeleteTimeout = 0
+	cfg.ChartNamespace = "Kyma.System"
+	cfg.ChartPath = "./not-existing"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ChartNamespace: ")
+	assert.Contains(t, err.Error(), "ChartPath: ")
+	assert.Contains(t, err.Error(), "HardDeleteTimeout: duration must be positive")
+}
+
+func TestLoadConfig(t *testing.T) {
+	// given
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configName, Namespace: kymaNamespace},
+		Data:       map[string]string{"ProcessingStateRequeueInterval": "10s", "ReadyTimeout": "never"},
+	}
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(cm).Build()
+	r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+	base := DefaultConfig()
+	base.CredentialsCheck = true
+	r.SetConfig(base)
+
+	// when
+	cfg, rejected, err := r.loadConfig(context.Background(), client.ObjectKeyFromObject(cm))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, cfg, r.config())
+	assert.Equal(t, time.Second*10, cfg.ProcessingStateRequeueInterval)
+	assert.True(t, cfg.CredentialsCheck, "base config should be kept")
+	assert.Equal(t, []string{"ReadyTimeout"}, keys(rejected))
+
+	annotated := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cm), annotated))
+	effective := map[string]string{}
+	require.NoError(t, json.Unmarshal([]byte(annotated.Annotations[effectiveConfigAnnotation]), &effective))
+	assert.Equal(t, "10s", effective["ProcessingStateRequeueInterval"])
+	assert.Equal(t, "1m0s", effective["ReadyTimeout"])
+	assert.Contains(t, annotated.Annotations[rejectedConfigKeysAnnotation], `"ReadyTimeout":"time: invalid duration`)
+
+	// when
+	require.NoError(t, c.Delete(context.Background(), annotated))
+	cfg, rejected, err = r.loadConfig(context.Background(), client.ObjectKeyFromObject(cm))
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	assert.Equal(t, base, cfg)
+	assert.Equal(t, base, r.config())
+}
+
+func TestConfigOfReconcilerWithoutConfig(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+
+	assert.Equal(t, DefaultConfig(), r.config())
+	assert.Equal(t, client.ObjectKey{Namespace: kymaNamespace, Name: configName}, r.configMapKey())
+}
+
+func keys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// maxListedObjects limits the number of affected objects named in the condition message per incompatibility
+const maxListedObjects = 10
+
+// schemaField is a field of the CRD schema, the path segment "[]" stands for the items of an array
+// and "*" for the values of a map
+type schemaField struct {
+	path              []string
+	fieldType         string
+	preservesUnknowns bool
+}
+
+// checkCRDsCompatibility compares the CRDs in the cluster with the incoming ones and returns an error
+// with the IncompatibleCRDUpgrade reason if the upgrade removes a version or a field still used by existing objects
+func (r *BtpOperatorReconciler) checkCRDsCompatibility(ctx context.Context, us []*unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+
+	incompatibilities := make([]string, 0)
+	for _, u := range us {
+		if u.GetKind() != crdKind {
+			continue
+		}
+		incoming := &apiextensionsv1.CustomResourceDefinition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, incoming); err != nil {
+			return fmt.Errorf("while converting %s CRD: %w", u.GetName(), err)
+		}
+		existing, err := r.getExistingCRD(ctx, u.GetName())
+		if err != nil {
+			return fmt.Errorf("while getting %s CRD: %w", u.GetName(), err)
+		}
+		if existing == nil {
+			continue
+		}
+		found, err := r.crdIncompatibilities(ctx, existing, incoming)
+		if err != nil {
+			return fmt.Errorf("while checking %s CRD compatibility: %w", u.GetName(), err)
+		}
+		incompatibilities = append(incompatibilities, found...)
+	}
+
+	if len(incompatibilities) == 0 {
+		return nil
+	}
+	logger.Info("incompatible CRD upgrade", "incompatibilities", incompatibilities)
+	return NewErrorWithReason(IncompatibleCRDUpgrade,
+		fmt.Sprintf("CRD upgrade blocked, existing objects are incompatible with the new CRDs: %s", strings.Join(incompatibilities, "; ")))
+}
+
+func (r *BtpOperatorReconciler) getExistingCRD(ctx context.Context, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind(crdKind))
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, u); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, crd); err != nil {
+		return nil, err
+	}
+	return crd, nil
+}
+
+// crdIncompatibilities lists the versions removed by the incoming CRD while objects exist, and the fields removed
+// from the schema or changed to another type which are set in existing objects. Objects are listed only if the CRDs differ
+// in one of these ways, so an unchanged CRD does not cost a cluster-wide list in every reconciliation.
+func (r *BtpOperatorReconciler) crdIncompatibilities(ctx context.Context, existing, incoming *apiextensionsv1.CustomResourceDefinition) ([]string, error) {
+	incompatibilities := make([]string, 0)
+	incomingVersions := make(map[string]*apiextensionsv1.CustomResourceDefinitionVersion)
+	for i := range incoming.Spec.Versions {
+		incomingVersions[incoming.Spec.Versions[i].Name] = &incoming.Spec.Versions[i]
+	}
+
+	servedVersions := sets.NewString()
+	for _, version := range existing.Spec.Versions {
+		if version.Served {
+			servedVersions.Insert(version.Name)
+		}
+	}
+	removedVersions := sets.NewString()
+	for _, version := range servedVersions.Union(sets.NewString(existing.Status.StoredVersions...)).List() {
+		if v, found := incomingVersions[version]; !found || !v.Served {
+			removedVersions.Insert(version)
+		}
+	}
+
+	changesPerVersion := make(map[string][]schemaChange)
+	for _, version := range existing.Spec.Versions {
+		incomingVersion, found := incomingVersions[version.Name]
+		if !found || !version.Served || version.Schema == nil || incomingVersion.Schema == nil {
+			continue
+		}
+		if changes := schemaChanges(version.Schema.OpenAPIV3Schema, incomingVersion.Schema.OpenAPIV3Schema); len(changes) > 0 {
+			changesPerVersion[version.Name] = changes
+		}
+	}
+
+	if removedVersions.Len() == 0 && len(changesPerVersion) == 0 {
+		return incompatibilities, nil
+	}
+
+	listed := make(map[string][]unstructured.Unstructured)
+	objectsOf := func(version string) ([]unstructured.Unstructured, error) {
+		if objects, found := listed[version]; found {
+			return objects, nil
+		}
+		objects, err := r.listCRDObjects(ctx, existing, version)
+		if err != nil {
+			return nil, err
+		}
+		listed[version] = objects
+		return objects, nil
+	}
+
+	if removedVersions.Len() > 0 {
+		objects, err := objectsOf(storageVersion(existing))
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) > 0 {
+			for _, version := range removedVersions.List() {
+				incompatibilities = append(incompatibilities, fmt.Sprintf("%s version %s removed, but %s",
+					existing.Name, version, describeObjects(objects)))
+			}
+		}
+	}
+
+	for _, version := range existing.Spec.Versions {
+		changes, found := changesPerVersion[version.Name]
+		if !found {
+			continue
+		}
+		objects, err := objectsOf(version.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes {
+			affected := objectsWithField(objects, change.field.path)
+			if len(affected) == 0 {
+				continue
+			}
+			incompatibilities = append(incompatibilities, fmt.Sprintf("%s version %s field %s %s, but %s",
+				existing.Name, version.Name, formatFieldPath(change.field.path), change.description, describeObjects(affected)))
+		}
+	}
+
+	return incompatibilities, nil
+}
+
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	return ""
+}
+
+func (r *BtpOperatorReconciler) listCRDObjects(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition, version string) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: version, Kind: crd.Spec.Names.ListKind})
+	if err := r.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("while listing %s objects: %w", crd.Spec.Names.Kind, err)
+	}
+	return list.Items, nil
+}
+
+type schemaChange struct {
+	field       schemaField
+	description string
+}
+
+// schemaChanges returns the fields of the existing schema which are removed from the incoming schema, unless the incoming
+// schema preserves unknown fields at their path, and the fields whose type changes. Nested fields of a removed field are not listed.
+func schemaChanges(existing, incoming *apiextensionsv1.JSONSchemaProps) []schemaChange {
+	existingFields := schemaFields(nil, existing)
+	incomingFields := make(map[string]schemaField)
+	for _, field := range schemaFields(nil, incoming) {
+		incomingFields[formatFieldPath(field.path)] = field
+	}
+
+	changes := make([]schemaChange, 0)
+	removed := make([]string, 0)
+	for _, field := range existingFields {
+		path := formatFieldPath(field.path)
+		if underRemovedField(path, removed) {
+			continue
+		}
+		incomingField, found := incomingFields[path]
+		switch {
+		case !found && !preservedUnknown(field.path, incomingFields):
+			removed = append(removed, path)
+			changes = append(changes, schemaChange{field: field, description: "removed"})
+		case found && field.fieldType != "" && incomingField.fieldType != "" && field.fieldType != incomingField.fieldType:
+			changes = append(changes, schemaChange{field: field,
+				description: fmt.Sprintf("changed from %s to %s", field.fieldType, incomingField.fieldType)})
+		}
+	}
+	return changes
+}
+
+// schemaFields returns the fields of the schema sorted by the path, so parents precede their nested fields
+func schemaFields(path []string, props *apiextensionsv1.JSONSchemaProps) []schemaField {
+	if props == nil {
+		return nil
+	}
+	fields := make([]schemaField, 0)
+	if len(path) > 0 {
+		preserves := props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields
+		fields = append(fields, schemaField{path: path, fieldType: props.Type, preservesUnknowns: preserves})
+	}
+	names := make([]string, 0, len(props.Properties))
+	for name := range props.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property := props.Properties[name]
+		fields = append(fields, schemaFields(appendPath(path, name), &property)...)
+	}
+	if props.Items != nil {
+		fields = append(fields, schemaFields(appendPath(path, "[]"), props.Items.Schema)...)
+	}
+	if props.AdditionalProperties != nil {
+		fields = append(fields, schemaFields(appendPath(path, "*"), props.AdditionalProperties.Schema)...)
+	}
+	return fields
+}
+
+func appendPath(path []string, segment string) []string {
+	result := make([]string, 0, len(path)+1)
+	return append(append(result, path...), segment)
+}
+
+func underRemovedField(path string, removed []string) bool {
+	for _, parent := range removed {
+		if strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[]") {
+			return true
+		}
+	}
+	return false
+}
+
+// preservedUnknown tells whether the incoming schema keeps unknown fields of any parent of the path
+func preservedUnknown(path []string, incomingFields map[string]schemaField) bool {
+	for i := len(path) - 1; i > 0; i-- {
+		if field, found := incomingFields[formatFieldPath(path[:i])]; found && field.preservesUnknowns {
+			return true
+		}
+	}
+	return false
+}
+
+func formatFieldPath(path []string) string {
+	var b strings.Builder
+	for _, segment := range path {
+		if segment != "[]" {
+			b.WriteString(".")
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+func objectsWithField(objects []unstructured.Unstructured, path []string) []unstructured.Unstructured {
+	result := make([]unstructured.Unstructured, 0)
+	for _, object := range objects {
+		if hasField(object.Object, path) {
+			result = append(result, object)
+		}
+	}
+	return result
+}
+
+func hasField(value interface{}, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	switch segment := path[0]; segment {
+	case "[]":
+		items, _ := value.([]interface{})
+		for _, item := range items {
+			if hasField(item, path[1:]) {
+				return true
+			}
+		}
+	case "*":
+		fields, _ := value.(map[string]interface{})
+		for _, field := range fields {
+			if hasField(field, path[1:]) {
+				return true
+			}
+		}
+	default:
+		fields, _ := value.(map[string]interface{})
+		if field, found := fields[segment]; found {
+			return hasField(field, path[1:])
+		}
+	}
+	return false
+}
+
+// describeObjects names the objects, listing at most maxListedObjects of them
+func describeObjects(objects []unstructured.Unstructured) string {
+	names := make([]string, 0, len(objects))
+	for _, object := range objects {
+		names = append(names, fmt.Sprintf("%s/%s", object.GetNamespace(), object.GetName()))
+	}
+	sort.Strings(names)
+	if len(names) > maxListedObjects {
+		names = append(names[:maxListedObjects], fmt.Sprintf("and %d more", len(objects)-maxListedObjects))
+	}
+	return fmt.Sprintf("%d object(s) exist: %s", len(objects), strings.Join(names, ", "))
+}
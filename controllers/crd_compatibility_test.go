@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testCRDGroup = "services.cloud.sap.com"
+	testCRDName  = "servicebindings." + testCRDGroup
+)
+
+func testCRD(t *testing.T, versions ...apiextensionsv1.CustomResourceDefinitionVersion) *unstructured.Unstructured {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group:    testCRDGroup,
+			Names:    apiextensionsv1.CustomResourceDefinitionNames{Kind: "ServiceBinding", ListKind: "ServiceBindingList"},
+			Versions: versions,
+		},
+	}
+	for _, version := range versions {
+		if version.Storage {
+			crd.Status.StoredVersions = append(crd.Status.StoredVersions, version.Name)
+		}
+	}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crd)
+	require.NoError(t, err)
+	u := &unstructured.Unstructured{Object: object}
+	u.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind(crdKind))
+	u.SetName(testCRDName)
+	return u
+}
+
+func testCRDVersion(name string, storage bool, specProperties map[string]apiextensionsv1.JSONSchemaProps) apiextensionsv1.CustomResourceDefinitionVersion {
+	return apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:    name,
+		Served:  true,
+		Storage: storage,
+		Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"spec": {Type: "object", Properties: specProperties},
+			},
+		}},
+	}
+}
+
+func testServiceBinding(version, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	u := testUnstructured(schema.GroupVersionKind{Group: testCRDGroup, Version: version, Kind: "ServiceBinding"}, kymaNamespace, name)
+	u.Object["spec"] = spec
+	return u
+}
+
+func crdCompatibilityTestReconciler(objects ...client.Object) *BtpOperatorReconciler {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	for _, version := range []string{"v1", "v1alpha1"} {
+		gv := schema.GroupVersion{Group: testCRDGroup, Version: version}
+		scheme.AddKnownTypeWithName(gv.WithKind("ServiceBinding"), &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gv.WithKind("ServiceBindingList"), &unstructured.UnstructuredList{})
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return NewBtpOperatorReconciler(c, scheme)
+}
+
+func TestCRDsCompatibility(t *testing.T) {
+	specProperties := map[string]apiextensionsv1.JSONSchemaProps{
+		"secretName": {Type: "string"},
+		"parameters": {Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{"plan": {Type: "string"}}},
+		"userInfo":   {Type: "string"},
+	}
+	preserveUnknownFields := true
+	withoutParameters := map[string]apiextensionsv1.JSONSchemaProps{"secretName": {Type: "string"}, "userInfo": {Type: "string"}}
+	preservingParameters := map[string]apiextensionsv1.JSONSchemaProps{
+		"secretName": {Type: "string"},
+		"parameters": {Type: "object", XPreserveUnknownFields: &preserveUnknownFields},
+		"userInfo":   {Type: "string"},
+	}
+	withUserInfoObject := map[string]apiextensionsv1.JSONSchemaProps{
+		"secretName": {Type: "string"},
+		"parameters": {Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{"plan": {Type: "string"}}},
+		"userInfo":   {Type: "object"},
+	}
+	existing := testCRD(t, testCRDVersion("v1", true, specProperties), testCRDVersion("v1alpha1", false, specProperties))
+	binding := testServiceBinding("v1", "binding", map[string]interface{}{"secretName": "secret", "parameters": map[string]interface{}{"plan": "standard"}})
+
+	for name, tc := range map[string]struct {
+		objects     []client.Object
+		incoming    *unstructured.Unstructured
+		expectedErr string
+	}{
+		"new CRD": {
+			incoming: testCRD(t, testCRDVersion("v1", true, withoutParameters)),
+		},
+		"unchanged CRD": {
+			objects:  []client.Object{existing, binding},
+			incoming: existing,
+		},
+		"removed version without objects": {
+			objects:  []client.Object{existing},
+			incoming: testCRD(t, testCRDVersion("v1", true, withoutParameters)),
+		},
+		"removed version with objects": {
+			objects:     []client.Object{existing, binding},
+			incoming:    testCRD(t, testCRDVersion("v1", true, specProperties)),
+			expectedErr: testCRDName + " version v1alpha1 removed, but 1 object(s) exist: kyma-system/binding",
+		},
+		"removed field set in objects": {
+			objects:     []client.Object{existing, binding},
+			incoming:    testCRD(t, testCRDVersion("v1", true, withoutParameters), testCRDVersion("v1alpha1", false, specProperties)),
+			expectedErr: testCRDName + " version v1 field .spec.parameters removed, but 1 object(s) exist: kyma-system/binding",
+		},
+		"removed field preserved as unknown": {
+			objects:  []client.Object{existing, binding},
+			incoming: testCRD(t, testCRDVersion("v1", true, preservingParameters), testCRDVersion("v1alpha1", false, specProperties)),
+		},
+		"changed type of field not set in objects": {
+			objects:  []client.Object{existing, binding},
+			incoming: testCRD(t, testCRDVersion("v1", true, withUserInfoObject), testCRDVersion("v1alpha1", false, specProperties)),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// given
+			r := crdCompatibilityTestReconciler(tc.objects...)
+
+			// when
+			err := r.checkCRDsCompatibility(context.Background(), []*unstructured.Unstructured{tc.incoming})
+
+			// then
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, IncompatibleCRDUpgrade, reasonOf(err, ReconcileFailed))
+			assert.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
+
+// countingListClient counts the List calls, which go around the cache for unstructured objects
+type countingListClient struct {
+	client.Client
+	lists int
+}
+
+func (c *countingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists++
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestCRDsCompatibilityListsObjectsOnlyForChangedCRDs(t *testing.T) {
+	// given
+	specProperties := map[string]apiextensionsv1.JSONSchemaProps{"secretName": {Type: "string"}}
+	existing := testCRD(t, testCRDVersion("v1", true, specProperties), testCRDVersion("v1alpha1", false, specProperties))
+	r := crdCompatibilityTestReconciler(existing, testServiceBinding("v1", "binding", map[string]interface{}{"secretName": "secret"}))
+	c := &countingListClient{Client: r.Client}
+	r.Client = c
+
+	// when
+	err := r.checkCRDsCompatibility(context.Background(), []*unstructured.Unstructured{existing})
+
+	// then
+	require.NoError(t, err)
+	assert.Zero(t, c.lists)
+
+	// when
+	err = r.checkCRDsCompatibility(context.Background(), []*unstructured.Unstructured{testCRD(t, testCRDVersion("v1", true, specProperties))})
+
+	// then
+	require.Error(t, err)
+	assert.Equal(t, 1, c.lists)
+}
+
+func TestSchemaChanges(t *testing.T) {
+	// given
+	existing := &apiextensionsv1.JSONSchemaProps{Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{
+		"spec": {Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"parameters": {Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{"plan": {Type: "string"}}},
+			"labels": {Type: "object", AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{
+				Schema: &apiextensionsv1.JSONSchemaProps{Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+			}},
+		}},
+	}}
+	incoming := &apiextensionsv1.JSONSchemaProps{Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{
+		"spec": {Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"labels": {Type: "object", AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{
+				Schema: &apiextensionsv1.JSONSchemaProps{Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "integer"}}},
+			}},
+		}},
+	}}
+
+	// when
+	changes := schemaChanges(existing, incoming)
+
+	// then
+	got := make([]string, 0, len(changes))
+	for _, change := range changes {
+		got = append(got, formatFieldPath(change.field.path)+" "+change.description)
+	}
+	assert.Equal(t, []string{".spec.labels.*[] changed from string to integer", ".spec.parameters removed"}, got)
+}
+
+func TestHasField(t *testing.T) {
+	object := map[string]interface{}{"spec": map[string]interface{}{
+		"labels": map[string]interface{}{"team": []interface{}{"a"}},
+		"empty":  []interface{}{},
+	}}
+
+	assert.True(t, hasField(object, []string{"spec", "labels", "*", "[]"}))
+	assert.False(t, hasField(object, []string{"spec", "empty", "[]"}))
+	assert.False(t, hasField(object, []string{"spec", "parameters"}))
+}
+
+func TestDescribeObjects(t *testing.T) {
+	objects := make([]unstructured.Unstructured, 0)
+	for i := 0; i < maxListedObjects+2; i++ {
+		objects = append(objects, *testServiceBinding("v1", fmt.Sprintf("binding-%02d", i), nil))
+	}
+
+	description := describeObjects(objects)
+
+	assert.Contains(t, description, "12 object(s) exist: kyma-system/binding-00, ")
+	assert.Contains(t, description, "kyma-system/binding-09, and 2 more")
+	assert.NotContains(t, description, "binding-10")
+}
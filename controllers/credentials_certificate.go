@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	clientSecretKey = "clientsecret"
+	tokenURLKey     = "tokenurl"
+	certURLKey      = "certurl"
+)
+
+var (
+	clientSecretCredentialsKeys = []string{clientSecretKey, tokenURLKey}
+	certificateCredentialsKeys  = []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey, certURLKey}
+)
+
+// usesCertificateCredentials tells if the Secret holds X.509 credentials instead of a client secret
+func usesCertificateCredentials(secret *corev1.Secret) bool {
+	_, exists := secret.Data[corev1.TLSCertKey]
+	return exists
+}
+
+// credentialsKeysFor returns the required keys together with the keys required in the Secret for its type of credentials
+func credentialsKeysFor(secret *corev1.Secret, requiredKeys []string) []string {
+	keys := append([]string{}, requiredKeys...)
+	if usesCertificateCredentials(secret) {
+		return append(keys, certificateCredentialsKeys...)
+	}
+	return append(keys, clientSecretCredentialsKeys...)
+}
+
+// parseCredentialsCertificate verifies that the certificate and the private key from the Secret form a pair
+// and that the certificate is currently valid
+func parseCredentialsCertificate(secret *corev1.Secret, now time.Time) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate and key pair: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+	if now.Before(cert.NotBefore) {
+		return nil, fmt.Errorf("certificate is not valid before %s", cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return cert, nil
+}
+
+// setCertificateCondition warns with the CredentialsCertificate condition when the certificate expires
+// within CertificateExpiryWarningPeriod. The condition is removed for client secret credentials.
+func (r *BtpOperatorReconciler) setCertificateCondition(cr *v1alpha1.BtpOperator, secret *corev1.Secret) {
+	if !usesCertificateCredentials(secret) {
+		RemoveStatusCondition(&cr.Status.Conditions, CredentialsCertificateType)
+		return
+	}
+	cert, err := parseCredentialsCertificate(secret, time.Now())
+	if err != nil {
+		return
+	}
+
+	expiry := cert.NotAfter.UTC().Format(time.RFC3339)
+	if time.Until(cert.NotAfter) > r.config().CertificateExpiryWarningPeriod {
+		SetStatusCondition(&cr.Status.Conditions, *ConditionFromExistingReason(CertificateValid,
+			fmt.Sprintf("Certificate expires at %s", expiry)))
+		return
+	}
+	message := fmt.Sprintf("Certificate expires at %s, replace the credentials before it expires", expiry)
+	if previous := FindStatusCondition(cr.Status.Conditions, CredentialsCertificateType); previous == nil || previous.Reason != string(CertificateExpiresSoon) {
+		r.recordEvent(cr, corev1.EventTypeWarning, CertificateExpiresSoon, message)
+	}
+	SetStatusCondition(&cr.Status.Conditions, *ConditionFromExistingReason(CertificateExpiresSoon, message))
+}
+
+// setTLSSecretValues sets the certificate and the private key from the Secret in the sap-btp-operator TLS Secret
+func (r *BtpOperatorReconciler) setTLSSecretValues(secret *corev1.Secret, u *unstructured.Unstructured) error {
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if err := unstructured.SetNestedField(u.Object, base64.StdEncoding.EncodeToString(secret.Data[key]), "data", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withCredentialsTLSSecret makes sure the module resources contain exactly one sap-btp-operator TLS Secret when
+// the Secret holds X.509 credentials, the pre-rendered manifests do not contain it and the rendered chart may contain two
+func withCredentialsTLSSecret(us []*unstructured.Unstructured, secret *corev1.Secret, namespace string) []*unstructured.Unstructured {
+	if secret == nil || !usesCertificateCredentials(secret) {
+		return us
+	}
+	result := make([]*unstructured.Unstructured, 0, len(us)+1)
+	found := false
+	for _, u := range us {
+		if u.GetKind() == secretKind && u.GetName() == btpServiceOperatorTlsSecret {
+			if found {
+				continue
+			}
+			found = true
+		}
+		result = append(result, u)
+	}
+	if !found {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{"type": string(corev1.SecretTypeTLS)}}
+		u.SetAPIVersion("v1")
+		u.SetKind(secretKind)
+		u.SetName(btpServiceOperatorTlsSecret)
+		u.SetNamespace(namespace)
+		result = append(result, u)
+	}
+	return result
+}
+
+// certificateCredentialsError returns an error describing why the certificate credentials cannot be used
+func certificateCredentialsError(secret *corev1.Secret) error {
+	if !usesCertificateCredentials(secret) {
+		return nil
+	}
+	if len(secret.Data[corev1.TLSCertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return nil
+	}
+	_, err := parseCredentialsCertificate(secret, time.Now())
+	return err
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+)
+
+// testCertificate returns a PEM encoded self-signed certificate valid until notAfter and its private key
+func testCertificate(t *testing.T, notAfter time.Time) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "btp-manager"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func certificateCredentialsSecret(t *testing.T, notAfter time.Time) *corev1.Secret {
+	crt, key := testCertificate(t, notAfter)
+	return &corev1.Secret{Data: map[string][]byte{
+		"clientid":              []byte("id"),
+		"sm_url":                []byte("https://sm.example.com"),
+		"cluster_id":            []byte("cluster"),
+		certURLKey:              []byte("https://cert.example.com"),
+		corev1.TLSCertKey:       crt,
+		corev1.TLSPrivateKeyKey: key,
+	}}
+}
+
+func TestVerifySecretWithCertificateCredentials(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+
+	t.Run("should accept valid certificate credentials without client secret", func(t *testing.T) {
+		assert.NoError(t, r.verifySecret(certificateCredentialsSecret(t, time.Now().Add(time.Hour))))
+	})
+	t.Run("should require certurl", func(t *testing.T) {
+		secret := certificateCredentialsSecret(t, time.Now().Add(time.Hour))
+		delete(secret.Data, certURLKey)
+		assert.ErrorContains(t, r.verifySecret(secret), "key(s) certurl not found")
+	})
+	t.Run("should reject mismatched certificate and key", func(t *testing.T) {
+		secret := certificateCredentialsSecret(t, time.Now().Add(time.Hour))
+		_, otherKey := testCertificate(t, time.Now().Add(time.Hour))
+		secret.Data[corev1.TLSPrivateKeyKey] = otherKey
+		assert.ErrorContains(t, r.verifySecret(secret), "invalid certificate and key pair")
+	})
+	t.Run("should reject expired certificate", func(t *testing.T) {
+		secret := certificateCredentialsSecret(t, time.Now().Add(-time.Minute))
+		assert.ErrorContains(t, r.verifySecret(secret), "certificate expired at")
+	})
+}
+
+func TestSetCertificateCondition(t *testing.T) {
+	// given
+	recorder := record.NewFakeRecorder(2)
+	r := &BtpOperatorReconciler{Recorder: recorder}
+	cr := &v1alpha1.BtpOperator{}
+
+	// when
+	r.setCertificateCondition(cr, certificateCredentialsSecret(t, time.Now().Add(2*DefaultConfig().CertificateExpiryWarningPeriod)))
+
+	// then
+	condition := FindStatusCondition(cr.Status.Conditions, CredentialsCertificateType)
+	require.NotNil(t, condition)
+	assert.Equal(t, string(CertificateValid), condition.Reason)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+
+	// when
+	r.setCertificateCondition(cr, certificateCredentialsSecret(t, time.Now().Add(DefaultConfig().CertificateExpiryWarningPeriod/2)))
+	r.setCertificateCondition(cr, certificateCredentialsSecret(t, time.Now().Add(DefaultConfig().CertificateExpiryWarningPeriod/2)))
+
+	// then
+	condition = FindStatusCondition(cr.Status.Conditions, CredentialsCertificateType)
+	require.NotNil(t, condition)
+	assert.Equal(t, string(CertificateExpiresSoon), condition.Reason)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, string(CertificateExpiresSoon))
+
+	// when
+	r.setCertificateCondition(cr, &corev1.Secret{Data: map[string][]byte{clientSecretKey: []byte("secret")}})
+
+	// then
+	assert.Nil(t, FindStatusCondition(cr.Status.Conditions, CredentialsCertificateType))
+}
+
+func TestSetSecretValuesWithCertificateCredentials(t *testing.T) {
+	// given
+	r := &BtpOperatorReconciler{}
+	secret := certificateCredentialsSecret(t, time.Now().Add(time.Hour))
+	u := testUnstructured(secretGvk, kymaNamespace, btpServiceOperatorSecret)
+	require.NoError(t, unstructured.SetNestedField(u.Object, "c2VjcmV0", "data", clientSecretKey))
+
+	// when
+	require.NoError(t, r.setSecretValues(secret, u))
+
+	// then
+	data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("https://cert.example.com")), data[tokenURLKey])
+	assert.NotContains(t, data, clientSecretKey)
+	assert.NotContains(t, data, corev1.TLSCertKey)
+	assert.NotContains(t, data, corev1.TLSPrivateKeyKey)
+	assert.NotContains(t, data, certURLKey)
+}
+
+func TestWithCredentialsTLSSecret(t *testing.T) {
+	secret := certificateCredentialsSecret(t, time.Now().Add(time.Hour))
+
+	t.Run("should add TLS Secret missing in the module resources", func(t *testing.T) {
+		us := withCredentialsTLSSecret([]*unstructured.Unstructured{testUnstructured(secretGvk, kymaNamespace, btpServiceOperatorSecret)}, secret, kymaNamespace)
+		require.Len(t, us, 2)
+		assert.Equal(t, btpServiceOperatorTlsSecret, us[1].GetName())
+		assert.Equal(t, string(corev1.SecretTypeTLS), us[1].Object["type"])
+	})
+	t.Run("should keep one TLS Secret", func(t *testing.T) {
+		us := withCredentialsTLSSecret([]*unstructured.Unstructured{
+			testUnstructured(secretGvk, kymaNamespace, btpServiceOperatorTlsSecret),
+			testUnstructured(secretGvk, kymaNamespace, btpServiceOperatorTlsSecret),
+		}, secret, kymaNamespace)
+		assert.Len(t, us, 1)
+	})
+	t.Run("should not change module resources for client secret credentials", func(t *testing.T) {
+		us := withCredentialsTLSSecret([]*unstructured.Unstructured{}, &corev1.Secret{}, kymaNamespace)
+		assert.Empty(t, us)
+	})
+}
+
+func TestGetChartValuesWithCertificateCredentials(t *testing.T) {
+	// given
+	r := &BtpOperatorReconciler{}
+	cfg := DefaultConfig()
+	cfg.ChartOverridesPath = ""
+	r.SetConfig(cfg)
+	secret := certificateCredentialsSecret(t, time.Now().Add(time.Hour))
+
+	// when
+	values, err := r.getChartValues(&v1alpha1.BtpOperator{}, secret)
+
+	// then
+	require.NoError(t, err)
+	secretValues, _, err := unstructured.NestedMap(values, "manager", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "https://cert.example.com", secretValues[tokenURLKey])
+	assert.Empty(t, secretValues[clientSecretKey])
+	assert.Equal(t, string(secret.Data[corev1.TLSCertKey]), secretValues["tls"].(map[string]interface{})["crt"])
+}
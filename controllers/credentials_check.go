@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	tokenPathSuffix          = "/oauth/token"
+	serviceManagerCheckPath  = "/v1/service_offerings"
+	credentialsResponseLimit = 1 << 20
+)
+
+// checkCredentials obtains an OAuth token from the tokenurl with the client credentials flow
+// and uses it to call the Service Manager, so that invalid credentials are reported before
+// SAP BTP Service Operator is installed. For X.509 credentials, the token is requested from the certurl
+// with the client certificate. The check is bounded by CredentialsCheckTimeout.
+func (r *BtpOperatorReconciler) checkCredentials(ctx context.Context, secret *corev1.Secret) *ErrorWithReason {
+	timeout := r.config().CredentialsCheckTimeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	httpClient := &http.Client{Timeout: timeout}
+	if usesCertificateCredentials(secret) {
+		pair, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return NewErrorWithReason(InvalidCredentials, fmt.Sprintf("invalid certificate and key pair: %s", err))
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12}
+		httpClient.Transport = transport
+	}
+
+	token, errWithReason := r.requestAccessToken(ctx, httpClient, secret)
+	if errWithReason != nil {
+		return errWithReason
+	}
+	return r.callServiceManager(ctx, httpClient, string(secret.Data["sm_url"]), token)
+}
+
+func (r *BtpOperatorReconciler) requestAccessToken(ctx context.Context, httpClient *http.Client, secret *corev1.Secret) (string, *ErrorWithReason) {
+	certificateCredentials := usesCertificateCredentials(secret)
+	tokenURLSource := tokenURLKey
+	if certificateCredentials {
+		tokenURLSource = certURLKey
+	}
+	tokenURL := strings.TrimSuffix(string(secret.Data[tokenURLSource]), "/")
+	if !strings.HasSuffix(tokenURL, tokenPathSuffix) {
+		tokenURL += tokenPathSuffix
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if certificateCredentials {
+		form.Set("client_id", string(secret.Data["clientid"]))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", NewErrorWithReason(InvalidCredentials, fmt.Sprintf("invalid token URL: %s", err))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !certificateCredentials {
+		req.SetBasicAuth(url.QueryEscape(string(secret.Data["clientid"])), url.QueryEscape(string(secret.Data[clientSecretKey])))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", NewErrorWithReason(ServiceManagerUnreachable, fmt.Sprintf("token endpoint %s unreachable: %s", tokenURL, unwrapURLError(err)))
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, credentialsResponseLimit))
+	if err != nil {
+		return "", NewErrorWithReason(ServiceManagerUnreachable, fmt.Sprintf("while reading response from token endpoint %s: %s", tokenURL, err))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", NewErrorWithReason(InvalidCredentials, fmt.Sprintf("token endpoint %s rejected the client credentials with status %d", tokenURL, resp.StatusCode))
+	case resp.StatusCode != http.StatusOK:
+		return "", NewErrorWithReason(ServiceManagerUnreachable, fmt.Sprintf("token endpoint %s responded with status %d", tokenURL, resp.StatusCode))
+	}
+
+	tokenResponse := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil || tokenResponse.AccessToken == "" {
+		return "", NewErrorWithReason(InvalidCredentials, fmt.Sprintf("token endpoint %s did not return an access token", tokenURL))
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+func (r *BtpOperatorReconciler) callServiceManager(ctx context.Context, httpClient *http.Client, smURL, token string) *ErrorWithReason {
+	checkURL := strings.TrimSuffix(smURL, "/") + serviceManagerCheckPath + "?max_items=1"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkURL, nil)
+	if err != nil {
+		return NewErrorWithReason(ServiceManagerUnreachable, fmt.Sprintf("invalid Service Manager URL: %s", err))
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return NewErrorWithReason(ServiceManagerUnreachable, fmt.Sprintf("Service Manager %s unreachable: %s", smURL, unwrapURLError(err)))
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, credentialsResponseLimit))
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return NewErrorWithReason(InvalidCredentials, fmt.Sprintf("Service Manager %s rejected the access token with status %d", smURL, resp.StatusCode))
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return NewErrorWithReason(ServiceManagerUnreachable, fmt.Sprintf("Service Manager %s responded with status %d", smURL, resp.StatusCode))
+	}
+	return nil
+}
+
+// unwrapURLError drops the method and URL added by the HTTP client, which are already part of the message
+func unwrapURLError(err error) error {
+	urlErr := &url.Error{}
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// fakeServiceManager serves the token endpoint and the Service Manager API for the given client credentials
+func fakeServiceManager(t *testing.T, clientID, clientSecret string, smStatus int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenPathSuffix, func(w http.ResponseWriter, req *http.Request) {
+		// client credentials are form-encoded in the Authorization header as required by RFC 6749
+		id, secret, ok := req.BasicAuth()
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+		if req.Method != http.MethodPost || req.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !ok || id != clientID || secret != clientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+	})
+	mux.HandleFunc(serviceManagerCheckPath, func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(smStatus)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func credentialsSecret(tokenURL, smURL, clientSecret string) *corev1.Secret {
+	return &corev1.Secret{Data: map[string][]byte{
+		"clientid":     []byte("sb-client!b1|service-manager!b2"),
+		"clientsecret": []byte(clientSecret),
+		"sm_url":       []byte(smURL),
+		"tokenurl":     []byte(tokenURL),
+		"cluster_id":   []byte("cluster"),
+	}}
+}
+
+func TestCheckCredentials(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+	server := fakeServiceManager(t, "sb-client!b1|service-manager!b2", "secret", http.StatusOK)
+
+	t.Run("should accept valid credentials", func(t *testing.T) {
+		assert.Nil(t, r.checkCredentials(context.Background(), credentialsSecret(server.URL, server.URL, "secret")))
+	})
+	t.Run("should accept token URL with the token path", func(t *testing.T) {
+		assert.Nil(t, r.checkCredentials(context.Background(), credentialsSecret(server.URL+tokenPathSuffix, server.URL+"/", "secret")))
+	})
+	t.Run("should report rejected client credentials", func(t *testing.T) {
+		errWithReason := r.checkCredentials(context.Background(), credentialsSecret(server.URL, server.URL, "wrong"))
+		require.NotNil(t, errWithReason)
+		assert.Equal(t, InvalidCredentials, errWithReason.reason)
+		assert.Contains(t, errWithReason.Error(), "status 401")
+	})
+	t.Run("should report rejected access token", func(t *testing.T) {
+		smServer := fakeServiceManager(t, "", "", http.StatusForbidden)
+		errWithReason := r.checkCredentials(context.Background(), credentialsSecret(server.URL, smServer.URL, "secret"))
+		require.NotNil(t, errWithReason)
+		assert.Equal(t, InvalidCredentials, errWithReason.reason)
+	})
+	t.Run("should report failing Service Manager", func(t *testing.T) {
+		failing := fakeServiceManager(t, "sb-client!b1|service-manager!b2", "secret", http.StatusServiceUnavailable)
+		errWithReason := r.checkCredentials(context.Background(), credentialsSecret(failing.URL, failing.URL, "secret"))
+		require.NotNil(t, errWithReason)
+		assert.Equal(t, ServiceManagerUnreachable, errWithReason.reason)
+		assert.Contains(t, errWithReason.Error(), "status 503")
+	})
+	t.Run("should report unreachable token endpoint", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		errWithReason := r.checkCredentials(context.Background(), credentialsSecret(closed.URL, server.URL, "secret"))
+		require.NotNil(t, errWithReason)
+		assert.Equal(t, ServiceManagerUnreachable, errWithReason.reason)
+	})
+}
+
+func TestCheckCredentialsTimeout(t *testing.T) {
+	// given
+	r := &BtpOperatorReconciler{}
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	defer hanging.Close()
+	defer close(release)
+	cfg := DefaultConfig()
+	cfg.CredentialsCheckTimeout = time.Millisecond * 100
+	r.SetConfig(cfg)
+
+	// when
+	start := time.Now()
+	errWithReason := r.checkCredentials(context.Background(), credentialsSecret(hanging.URL, hanging.URL, "secret"))
+
+	// then
+	require.NotNil(t, errWithReason)
+	assert.Equal(t, ServiceManagerUnreachable, errWithReason.reason)
+	assert.Less(t, time.Since(start), time.Second*5)
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	credentialsHashAnnotation = "operator.kyma-project.io/credentials-hash"
+
+	CredentialsRotated Reason = "CredentialsRotated"
+)
+
+// credentialsHash returns the content hash of the data of the given Secrets and ConfigMaps
+func credentialsHash(us ...*unstructured.Unstructured) (string, error) {
+	content := make([]map[string]interface{}, 0, len(us))
+	for _, u := range us {
+		content = append(content, map[string]interface{}{
+			"kind":       u.GetKind(),
+			"name":       u.GetName(),
+			"data":       u.Object["data"],
+			"stringData": u.Object["stringData"],
+		})
+	}
+	// encoding/json sorts map keys, so the same data always gives the same hash
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setCredentialsHash stamps the hash on the Pod template of the Deployment,
+// so that a change of the credentials triggers a rolling restart of sap-btp-operator
+func (r *BtpOperatorReconciler) setCredentialsHash(hash string, u *unstructured.Unstructured) error {
+	annotationsPath := []string{"spec", "template", "metadata", "annotations"}
+	annotations, _, err := unstructured.NestedStringMap(u.Object, annotationsPath...)
+	if err != nil {
+		return err
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[credentialsHashAnnotation] = hash
+	return unstructured.SetNestedStringMap(u.Object, annotations, annotationsPath...)
+}
+
+// setCredentialsStatus records the credentials hash stamped on the applied Deployment in the CR status,
+// together with the rotation time when the hash differs from the previously applied one
+func (r *BtpOperatorReconciler) setCredentialsStatus(cr *v1alpha1.BtpOperator, us []*unstructured.Unstructured) {
+	cfg := r.config()
+	var hash string
+	for _, u := range us {
+		if u.GetName() == cfg.DeploymentName && u.GetKind() == deploymentKind {
+			hash, _, _ = unstructured.NestedString(u.Object, "spec", "template", "metadata", "annotations", credentialsHashAnnotation)
+		}
+	}
+	if hash == "" {
+		return
+	}
+	if cr.Status.Credentials == nil {
+		cr.Status.Credentials = &v1alpha1.CredentialsStatus{}
+	}
+	previous := cr.Status.Credentials.Hash
+	if previous == hash {
+		return
+	}
+	cr.Status.Credentials.Hash = hash
+	if previous != "" {
+		cr.Status.Credentials.RotationTime = &metav1.Time{Time: time.Now()}
+		r.recordEvent(cr, corev1.EventTypeNormal, CredentialsRotated,
+			fmt.Sprintf("Credentials changed, rolling out %s/%s Deployment", cfg.ChartNamespace, cfg.DeploymentName))
+	}
+}
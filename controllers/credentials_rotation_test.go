@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+)
+
+func credentialsRotationResources(t *testing.T) []*unstructured.Unstructured {
+	configMap := testUnstructured(configMapGvk, kymaNamespace, btpServiceOperatorConfigMap)
+	secret := testUnstructured(secretGvk, kymaNamespace, btpServiceOperatorSecret)
+	deployment := testUnstructured(appsv1.SchemeGroupVersion.WithKind(deploymentKind), kymaNamespace, deploymentName)
+	require.NoError(t, unstructured.SetNestedStringMap(deployment.Object, map[string]string{"existing": "annotation"},
+		"spec", "template", "metadata", "annotations"))
+	return []*unstructured.Unstructured{configMap, secret, deployment}
+}
+
+func credentialsHashOf(t *testing.T, u *unstructured.Unstructured) string {
+	hash, _, err := unstructured.NestedString(u.Object, "spec", "template", "metadata", "annotations", credentialsHashAnnotation)
+	require.NoError(t, err)
+	return hash
+}
+
+func TestPrepareModuleResourcesSetsCredentialsHash(t *testing.T) {
+	// given
+	r := NewBtpOperatorReconciler(nil, nil)
+	cfg := DefaultConfig()
+	cfg.ChartPath = "../module-chart/chart"
+	r.SetConfig(cfg)
+	credentials := &corev1.Secret{Data: map[string][]byte{"clientid": []byte("id"), "cluster_id": []byte("cluster")}}
+	us := credentialsRotationResources(t)
+
+	// when
+	require.NoError(t, r.prepareModuleResources(context.Background(), &v1alpha1.BtpOperator{}, us, credentials))
+
+	// then
+	hash := credentialsHashOf(t, us[2])
+	assert.NotEmpty(t, hash)
+	annotations, _, _ := unstructured.NestedStringMap(us[2].Object, "spec", "template", "metadata", "annotations")
+	assert.Equal(t, "annotation", annotations["existing"])
+
+	// when
+	unchanged := credentialsRotationResources(t)
+	require.NoError(t, r.prepareModuleResources(context.Background(), &v1alpha1.BtpOperator{}, unchanged, credentials))
+	rotated := credentialsRotationResources(t)
+	credentials.Data["clientid"] = []byte("rotated")
+	require.NoError(t, r.prepareModuleResources(context.Background(), &v1alpha1.BtpOperator{}, rotated, credentials))
+
+	// then
+	assert.Equal(t, hash, credentialsHashOf(t, unchanged[2]))
+	assert.NotEqual(t, hash, credentialsHashOf(t, rotated[2]))
+}
+
+func TestSetCredentialsStatus(t *testing.T) {
+	// given
+	recorder := record.NewFakeRecorder(1)
+	r := &BtpOperatorReconciler{Recorder: recorder}
+	cr := &v1alpha1.BtpOperator{}
+	us := credentialsRotationResources(t)
+	require.NoError(t, r.setCredentialsHash("initial", us[2]))
+
+	// when
+	r.setCredentialsStatus(cr, us)
+
+	// then
+	require.NotNil(t, cr.Status.Credentials)
+	assert.Equal(t, "initial", cr.Status.Credentials.Hash)
+	assert.Nil(t, cr.Status.Credentials.RotationTime)
+	assert.Empty(t, recorder.Events)
+
+	// when
+	require.NoError(t, r.setCredentialsHash("rotated", us[2]))
+	r.setCredentialsStatus(cr, us)
+
+	// then
+	assert.Equal(t, "rotated", cr.Status.Credentials.Hash)
+	require.NotNil(t, cr.Status.Credentials.RotationTime)
+	assert.Contains(t, <-recorder.Events, string(CredentialsRotated))
+}
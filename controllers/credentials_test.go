@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateCredentialsSecretRef(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+
+	t.Run("should accept missing reference", func(t *testing.T) {
+		assert.NoError(t, r.validateCredentialsSecretRef(nil))
+	})
+	t.Run("should accept reference with known keys in mapping", func(t *testing.T) {
+		ref := &v1alpha1.CredentialsSecretRef{Name: "creds", KeyMapping: map[string]string{"clientid": "client_id", "sm_url": "url"}}
+		assert.NoError(t, r.validateCredentialsSecretRef(ref))
+	})
+	t.Run("should reject reference without name", func(t *testing.T) {
+		assert.Error(t, r.validateCredentialsSecretRef(&v1alpha1.CredentialsSecretRef{}))
+	})
+	t.Run("should reject unknown and empty mappings", func(t *testing.T) {
+		ref := &v1alpha1.CredentialsSecretRef{Name: "creds", KeyMapping: map[string]string{"foo": "bar", "tokenurl": ""}}
+		err := r.validateCredentialsSecretRef(ref)
+		assert.ErrorContains(t, err, "unknown key(s) foo")
+		assert.ErrorContains(t, err, "empty mapping for tokenurl")
+	})
+}
+
+func TestCredentialsSecretKey(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+
+	t.Run("should default to the configured Secret", func(t *testing.T) {
+		key := r.credentialsSecretKey(&v1alpha1.BtpOperator{})
+		assert.Equal(t, secretName, key.Name)
+		assert.Equal(t, kymaNamespace, key.Namespace)
+	})
+	t.Run("should default namespace of the referenced Secret", func(t *testing.T) {
+		cr := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{CredentialsSecretRef: &v1alpha1.CredentialsSecretRef{Name: "creds"}}}
+		key := r.credentialsSecretKey(cr)
+		assert.Equal(t, "creds", key.Name)
+		assert.Equal(t, kymaNamespace, key.Namespace)
+	})
+}
+
+func TestMapSecretKeys(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds"},
+		Data: map[string][]byte{
+			"client_id":    []byte("id"),
+			"clientid":     []byte("ignored"),
+			"clientsecret": []byte("secret"),
+		},
+	}
+
+	t.Run("should rename mapped keys", func(t *testing.T) {
+		mapped := r.mapSecretKeys(secret, map[string]string{"clientid": "client_id"})
+		assert.Equal(t, map[string][]byte{"clientid": []byte("id"), "clientsecret": []byte("secret")}, mapped.Data)
+		assert.Contains(t, secret.Data, "client_id", "source Secret must not be modified")
+	})
+	t.Run("should drop mapped keys missing in the Secret", func(t *testing.T) {
+		mapped := r.mapSecretKeys(secret, map[string]string{"clientid": "missing"})
+		assert.NotContains(t, mapped.Data, "clientid")
+	})
+	t.Run("should return the Secret unchanged without mapping", func(t *testing.T) {
+		assert.Same(t, secret, r.mapSecretKeys(secret, nil))
+	})
+}
+
+func TestUpdateStatusIfChanged(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "btpoperator", Namespace: kymaNamespace}}
+	cr.Status.WithState(types.StateReady)
+	SetStatusCondition(&cr.Status.Conditions, *ConditionFromExistingReason(ReconcileSucceeded, "Module provisioning succeeded"))
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(cr).Build()
+	r := NewBtpOperatorReconciler(c, s)
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cr), cr))
+	resourceVersion := cr.ResourceVersion
+
+	// when
+	err := r.updateStatusIfChanged(context.Background(), cr, cr.Status.DeepCopy())
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, resourceVersion, cr.ResourceVersion, "unchanged status should not be written")
+
+	// when
+	previous := cr.Status.DeepCopy()
+	SetStatusCondition(&cr.Status.Conditions, *ConditionFromExistingReason(CertificateExpiresSoon, "certificate expires soon"))
+	err = r.updateStatusIfChanged(context.Background(), cr, previous)
+
+	// then
+	require.NoError(t, err)
+	assert.NotEqual(t, resourceVersion, cr.ResourceVersion)
+	stored := &v1alpha1.BtpOperator{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cr), stored))
+	assert.Len(t, stored.Status.Conditions, 2)
+}
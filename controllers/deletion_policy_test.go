@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// deleteAllOfRecorder records kinds of resources passed to DeleteAllOf
+type deleteAllOfRecorder struct {
+	client.Client
+	kinds []string
+}
+
+func (c *deleteAllOfRecorder) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.kinds = append(c.kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func TestDeletingReason(t *testing.T) {
+	tests := []struct {
+		policy   v1alpha1.DeletionPolicy
+		expected Reason
+	}{
+		{policy: "", expected: HardDeleting},
+		{policy: v1alpha1.DeletionPolicyDelete, expected: HardDeleting},
+		{policy: v1alpha1.DeletionPolicySoftDeleteOnly, expected: SoftDeleting},
+		{policy: v1alpha1.DeletionPolicyOrphan, expected: Orphaning},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			cr := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{DeletionPolicy: tt.policy}}
+			assert.Equal(t, tt.expected, deletingReason(cr))
+		})
+	}
+}
+
+func TestHandleOrphanKeepsCrds(t *testing.T) {
+	// given
+	c := &deleteAllOfRecorder{Client: fake.NewClientBuilder().WithScheme(readinessTestScheme(t)).Build()}
+	r := NewBtpOperatorReconciler(c, c.Scheme())
+	useModuleResources(t, r, "configmap.yml", "crd.yml")
+	cr := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{DeletionPolicy: v1alpha1.DeletionPolicyOrphan}}
+	orphans := testutil.ToFloat64(deprovisioningCounter.WithLabelValues(orphanMode, resultSuccess))
+
+	// when
+	err := r.handleOrphan(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+	assert.Contains(t, c.kinds, configMapKind)
+	assert.NotContains(t, c.kinds, crdKind)
+	assert.Equal(t, orphans+1, testutil.ToFloat64(deprovisioningCounter.WithLabelValues(orphanMode, resultSuccess)))
+}
+
+// useModuleResources switches to pre-rendered module resources containing only the given files from module-resources/apply
+func useModuleResources(t *testing.T, r *BtpOperatorReconciler, files ...string) {
+	resourcesPath := t.TempDir()
+	applyDir := filepath.Join(resourcesPath, "apply")
+	require.NoError(t, os.MkdirAll(applyDir, 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(resourcesPath, "delete"), 0o755))
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join("..", "module-resources", "apply", file))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(applyDir, file), content, 0o644))
+	}
+	cfg := r.config()
+	cfg.ResourcesPath, cfg.RenderChart = resourcesPath, false
+	r.SetConfig(cfg)
+}
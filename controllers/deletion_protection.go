@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// handleDeletionProtection keeps the BtpOperator CR in Deleting state with the DeletionBlocked condition
+// while Service Instances or Service Bindings exist, unless the deletion is confirmed with the annotation.
+// It returns true if the deprovisioning must not start yet.
+func (r *BtpOperatorReconciler) handleDeletionProtection(ctx context.Context, cr *v1alpha1.BtpOperator) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if !deletionProtectionApplies(cr) {
+		return false, r.unblockDeletion(ctx, cr)
+	}
+
+	counts, _, err := r.listRemainingServiceResources(ctx)
+	if err != nil {
+		return true, fmt.Errorf("while counting Service Instances and Service Bindings: %w", err)
+	}
+	if len(counts) == 0 {
+		return false, r.unblockDeletion(ctx, cr)
+	}
+
+	msg := deletionBlockedMessage(counts)
+	logger.Info("Deletion blocked by the deletion protection", "reason", msg)
+	if condition := FindStatusCondition(cr.Status.Conditions, ReadyType); condition != nil &&
+		condition.Reason == string(DeletionBlocked) && condition.Message == msg {
+		return true, nil
+	}
+
+	return true, r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, DeletionBlocked, msg)
+}
+
+func deletionProtectionApplies(cr *v1alpha1.BtpOperator) bool {
+	if !cr.Spec.DeletionProtection || len(cr.GetFinalizers()) == 0 {
+		return false
+	}
+	// the Orphan and SoftDeleteOnly policies do not deprovision Service Instances and Service Bindings in SAP BTP
+	if policy := deletionPolicy(cr); policy == v1alpha1.DeletionPolicyOrphan || policy == v1alpha1.DeletionPolicySoftDeleteOnly {
+		return false
+	}
+	// once the deprovisioning has started, blocking it would let its deadline run out while it waits
+	if cr.Status.Deprovisioning != nil && cr.Status.Deprovisioning.Phase != "" {
+		return false
+	}
+	return cr.GetAnnotations()[v1alpha1.DeletionConfirmedAnnotation] != "true"
+}
+
+// unblockDeletion restores the deleting reason once the deletion is no longer blocked
+func (r *BtpOperatorReconciler) unblockDeletion(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+	condition := FindStatusCondition(cr.Status.Conditions, ReadyType)
+	if condition == nil || condition.Reason != string(DeletionBlocked) {
+		return nil
+	}
+	return r.UpdateBtpOperatorStatus(ctx, cr, types.StateDeleting, deletingReason(cr), "BtpOperator is to be deleted")
+}
+
+func deletionBlockedMessage(counts map[string]*serviceResourcesCount) string {
+	namespaces := make([]string, 0, len(counts))
+	for namespace := range counts {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	perNamespace := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		count := counts[namespace]
+		perNamespace = append(perNamespace, fmt.Sprintf("%s (Service Instances: %d, Service Bindings: %d)",
+			namespace, count.instances, count.bindings))
+	}
+
+	return fmt.Sprintf("Deletion blocked, Service Instances or Service Bindings exist in namespaces: %s. "+
+		"Remove them or set the %s annotation to \"true\" to confirm the deletion",
+		strings.Join(perNamespace, ", "), v1alpha1.DeletionConfirmedAnnotation)
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandleDeletionProtection(t *testing.T) {
+	tests := []struct {
+		name            string
+		protection      bool
+		policy          v1alpha1.DeletionPolicy
+		annotations     map[string]string
+		deprovisioning  *v1alpha1.DeprovisioningStatus
+		objects         []client.Object
+		expectedBlocked bool
+		expectedReason  Reason
+	}{
+		{
+			name:            "protection disabled",
+			objects:         []client.Object{testUnstructured(instanceGvk, "default", "si")},
+			expectedBlocked: false,
+			expectedReason:  HardDeleting,
+		},
+		{
+			name:            "no Service Instances and Service Bindings",
+			protection:      true,
+			expectedBlocked: false,
+			expectedReason:  HardDeleting,
+		},
+		{
+			name:       "Service Instances and Service Bindings exist",
+			protection: true,
+			objects: []client.Object{
+				testUnstructured(instanceGvk, "default", "si"),
+				testUnstructured(bindingGvk, "default", "sb"),
+				testUnstructured(instanceGvk, "test", "si"),
+			},
+			expectedBlocked: true,
+			expectedReason:  DeletionBlocked,
+		},
+		{
+			name:            "deletion confirmed",
+			protection:      true,
+			annotations:     map[string]string{v1alpha1.DeletionConfirmedAnnotation: "true"},
+			objects:         []client.Object{testUnstructured(instanceGvk, "default", "si")},
+			expectedBlocked: false,
+			expectedReason:  HardDeleting,
+		},
+		{
+			name:            "orphan policy",
+			protection:      true,
+			policy:          v1alpha1.DeletionPolicyOrphan,
+			objects:         []client.Object{testUnstructured(instanceGvk, "default", "si")},
+			expectedBlocked: false,
+			expectedReason:  HardDeleting,
+		},
+		{
+			name:            "soft delete only policy",
+			protection:      true,
+			policy:          v1alpha1.DeletionPolicySoftDeleteOnly,
+			objects:         []client.Object{testUnstructured(instanceGvk, "default", "si")},
+			expectedBlocked: false,
+			expectedReason:  HardDeleting,
+		},
+		{
+			name:            "deprovisioning already started",
+			protection:      true,
+			deprovisioning:  &v1alpha1.DeprovisioningStatus{Phase: v1alpha1.DeprovisioningHardDeleteStarted},
+			objects:         []client.Object{testUnstructured(instanceGvk, "default", "si")},
+			expectedBlocked: false,
+			expectedReason:  HardDeleting,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			s := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(s))
+			require.NoError(t, v1alpha1.AddToScheme(s))
+			cr := &v1alpha1.BtpOperator{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "protected",
+					Namespace:   "default",
+					Annotations: tt.annotations,
+					Finalizers:  []string{deletionFinalizer},
+				},
+				Spec: v1alpha1.BtpOperatorSpec{DeletionProtection: tt.protection, DeletionPolicy: tt.policy},
+			}
+			cr.Status.State = types.StateDeleting
+			cr.Status.Deprovisioning = tt.deprovisioning
+			cr.Status.Conditions = []*metav1.Condition{ConditionFromExistingReason(HardDeleting, "BtpOperator is to be deleted")}
+			objects := append([]client.Object{cr}, tt.objects...)
+			r := NewBtpOperatorReconciler(fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build(), s)
+			defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+
+			// when
+			blocked, err := r.handleDeletionProtection(context.Background(), cr)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedBlocked, blocked)
+			condition := FindStatusCondition(cr.Status.Conditions, ReadyType)
+			require.NotNil(t, condition)
+			assert.Equal(t, string(tt.expectedReason), condition.Reason)
+			assert.Equal(t, types.StateDeleting, cr.Status.State)
+		})
+	}
+}
+
+func TestHandleDeletionProtectionUnblocksDeletion(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cr := &v1alpha1.BtpOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "protected", Namespace: "default", Finalizers: []string{deletionFinalizer}},
+		Spec: v1alpha1.BtpOperatorSpec{
+			DeletionProtection: true,
+			DeletionPolicy:     v1alpha1.DeletionPolicySoftDeleteOnly,
+		},
+	}
+	cr.Status.State = types.StateDeleting
+	cr.Status.Conditions = []*metav1.Condition{ConditionFromExistingReason(DeletionBlocked, "Deletion blocked")}
+	r := NewBtpOperatorReconciler(fake.NewClientBuilder().WithScheme(s).WithObjects(cr).Build(), s)
+	defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+
+	// when
+	blocked, err := r.handleDeletionProtection(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+	assert.False(t, blocked)
+	condition := FindStatusCondition(cr.Status.Conditions, ReadyType)
+	require.NotNil(t, condition)
+	assert.Equal(t, string(SoftDeleting), condition.Reason)
+}
+
+func TestDeletionBlockedMessage(t *testing.T) {
+	// given
+	counts := map[string]*serviceResourcesCount{
+		"test":    {instances: 1},
+		"default": {instances: 2, bindings: 1},
+	}
+
+	// when
+	msg := deletionBlockedMessage(counts)
+
+	// then
+	assert.Equal(t, "Deletion blocked, Service Instances or Service Bindings exist in namespaces: "+
+		"default (Service Instances: 2, Service Bindings: 1), test (Service Instances: 1, Service Bindings: 0). "+
+		"Remove them or set the operator.kyma-project.io/deletion-confirmed annotation to \"true\" to confirm the deletion", msg)
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/btp-manager/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestSetDeploymentSettings(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+	replicas := int32(3)
+	settings := &v1alpha1.DeploymentSettings{
+		Replicas: &replicas,
+		Resources: &corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		},
+		NodeSelector: map[string]string{"pool": "btp"},
+		Tolerations: []corev1.Toleration{
+			{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "btp", Effect: corev1.TaintEffectNoSchedule},
+		},
+		Affinity: &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{TopologyKey: "kubernetes.io/hostname"}},
+				},
+			},
+		},
+		PriorityClassName: "btp-critical",
+	}
+
+	u := getModuleDeployment(t)
+	require.NoError(t, r.setDeploymentSettings(settings, u))
+	require.NoError(t, r.setDeploymentSettings(settings, u))
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, deployment))
+	podSpec := deployment.Spec.Template.Spec
+	assert.Equal(t, replicas, *deployment.Spec.Replicas)
+	assert.Equal(t, settings.NodeSelector, podSpec.NodeSelector)
+	assert.Equal(t, settings.Tolerations, podSpec.Tolerations)
+	assert.Equal(t, settings.Affinity, podSpec.Affinity)
+	assert.Equal(t, settings.PriorityClassName, podSpec.PriorityClassName)
+	for _, c := range podSpec.Containers {
+		if c.Name != managerContainerName {
+			assert.NotContains(t, c.Args, leaderElectionArg)
+			continue
+		}
+		assert.True(t, c.Resources.Limits.Memory().Equal(resource.MustParse("512Mi")))
+		assert.Nil(t, c.Resources.Requests)
+		assert.Equal(t, 1, countString(c.Args, leaderElectionArg))
+	}
+}
+
+func TestSetDeploymentSettingsKeepsRenderedValues(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+	u := getModuleDeployment(t)
+	expected := u.DeepCopy()
+
+	require.NoError(t, r.setDeploymentSettings(&v1alpha1.DeploymentSettings{}, u))
+
+	assert.Equal(t, expected, u)
+}
+
+func getModuleDeployment(t *testing.T) *unstructured.Unstructured {
+	h := &manifest.Handler{Scheme: clientgoscheme.Scheme}
+	objs, err := h.CollectObjectsFromDir("../module-resources/apply")
+	require.NoError(t, err)
+	us, err := h.ObjectsToUnstructured(objs)
+	require.NoError(t, err)
+	for _, u := range us {
+		if u.GetKind() == deploymentKind && u.GetName() == deploymentName {
+			return u
+		}
+	}
+	t.Fatalf("%s Deployment not found in module resources", deploymentName)
+	return nil
+}
+
+func countString(values []string, value string) int {
+	count := 0
+	for _, v := range values {
+		if v == value {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"gopkg.in/yaml.v3"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	deprovisioningPreviewConfigMap = "btp-manager-deprovisioning-preview"
+	deprovisioningPreviewKey       = "preview.yaml"
+
+	DeprovisioningPreviewGenerated Reason = "DeprovisioningPreviewGenerated"
+)
+
+// deprovisioningPreview lists resources which would be deleted by the deprovisioning with the current deletion policy
+type deprovisioningPreview struct {
+	GeneratedAt      string                  `yaml:"generatedAt"`
+	DeletionPolicy   v1alpha1.DeletionPolicy `yaml:"deletionPolicy"`
+	ServiceBindings  []previewServiceBinding `yaml:"serviceBindings"`
+	ServiceInstances []previewResource       `yaml:"serviceInstances"`
+	Deployment       *previewResource        `yaml:"deployment,omitempty"`
+	Webhooks         []previewResource       `yaml:"webhooks"`
+	ModuleResources  []previewResource       `yaml:"moduleResources"`
+}
+
+type previewResource struct {
+	Kind      string `yaml:"kind"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Name      string `yaml:"name"`
+}
+
+type previewServiceBinding struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	Secret    string `yaml:"secret"`
+}
+
+// handleDeprovisioningPreview writes the deprovisioning preview into a ConfigMap in the BtpOperator CR namespace
+// without deleting anything and removes the preview annotation from the CR
+func (r *BtpOperatorReconciler) handleDeprovisioningPreview(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+	logger := log.FromContext(ctx)
+	logger.Info("Generating deprovisioning preview")
+
+	preview, err := r.getDeprovisioningPreview(ctx, cr)
+	if err != nil {
+		return fmt.Errorf("while generating deprovisioning preview: %w", err)
+	}
+	if err := r.saveDeprovisioningPreview(ctx, cr, preview); err != nil {
+		return fmt.Errorf("while saving deprovisioning preview: %w", err)
+	}
+	r.recordEvent(cr, corev1.EventTypeNormal, DeprovisioningPreviewGenerated, fmt.Sprintf(
+		"Deprovisioning preview saved in %s/%s ConfigMap: %d Service Binding(s), %d Service Instance(s), %d module resource(s)",
+		cr.GetNamespace(), deprovisioningPreviewConfigMap, len(preview.ServiceBindings), len(preview.ServiceInstances), len(preview.ModuleResources)))
+
+	annotations := cr.GetAnnotations()
+	delete(annotations, v1alpha1.DeprovisioningPreviewAnnotation)
+	cr.SetAnnotations(annotations)
+
+	return r.Update(ctx, cr)
+}
+
+func (r *BtpOperatorReconciler) getDeprovisioningPreview(ctx context.Context, cr *v1alpha1.BtpOperator) (*deprovisioningPreview, error) {
+	preview := &deprovisioningPreview{
+		GeneratedAt:      time.Now().UTC().Format(time.RFC3339),
+		DeletionPolicy:   deletionPolicy(cr),
+		ServiceBindings:  make([]previewServiceBinding, 0),
+		ServiceInstances: make([]previewResource, 0),
+		Webhooks:         make([]previewResource, 0),
+		ModuleResources:  make([]previewResource, 0),
+	}
+
+	if preview.DeletionPolicy != v1alpha1.DeletionPolicyOrphan {
+		bindings, err := r.listServiceResources(ctx, r.GvkToList(bindingGvk))
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range bindings {
+			preview.ServiceBindings = append(preview.ServiceBindings, previewServiceBinding{
+				Namespace: item.GetNamespace(),
+				Name:      item.GetName(),
+				Secret:    bindingSecretName(&item),
+			})
+		}
+
+		instances, err := r.listServiceResources(ctx, r.GvkToList(instanceGvk))
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range instances {
+			preview.ServiceInstances = append(preview.ServiceInstances, toPreviewResource(&item))
+		}
+
+		if err := r.addSoftDeleteCleanupPreview(ctx, preview); err != nil {
+			return nil, err
+		}
+	}
+
+	resourcesToDelete, err := r.getModuleResourcesToDelete(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	for _, gvk := range uniqueGvks(resourcesToDelete) {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		if err := r.List(ctx, list, client.InNamespace(r.config().ChartNamespace), managedByLabelFilter); err != nil {
+			if meta.IsNoMatchError(err) || k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", gvk, err)
+		}
+		for i := range list.Items {
+			preview.ModuleResources = append(preview.ModuleResources, toPreviewResource(&list.Items[i]))
+		}
+	}
+	sort.Slice(preview.ModuleResources, func(i, j int) bool {
+		a, b := preview.ModuleResources[i], preview.ModuleResources[j]
+		return fmt.Sprintf("%s/%s/%s", a.Kind, a.Namespace, a.Name) < fmt.Sprintf("%s/%s/%s", b.Kind, b.Namespace, b.Name)
+	})
+
+	return preview, nil
+}
+
+// addSoftDeleteCleanupPreview adds the Deployment and webhooks deleted before the soft delete
+func (r *BtpOperatorReconciler) addSoftDeleteCleanupPreview(ctx context.Context, preview *deprovisioningPreview) error {
+	cfg := r.config()
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: cfg.DeploymentName, Namespace: cfg.ChartNamespace}, deployment); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		preview.Deployment = &previewResource{Kind: deploymentKind, Namespace: cfg.ChartNamespace, Name: cfg.DeploymentName}
+	}
+
+	if err := r.Get(ctx, client.ObjectKey{Name: mutatingWebhookName}, &admissionregistrationv1.MutatingWebhookConfiguration{}); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		preview.Webhooks = append(preview.Webhooks, previewResource{Kind: mutatingWebhookKind, Name: mutatingWebhookName})
+	}
+
+	if err := r.Get(ctx, client.ObjectKey{Name: validatingWebhookName}, &admissionregistrationv1.ValidatingWebhookConfiguration{}); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		preview.Webhooks = append(preview.Webhooks, previewResource{Kind: validatingWebhookKind, Name: validatingWebhookName})
+	}
+
+	return nil
+}
+
+func (r *BtpOperatorReconciler) listServiceResources(ctx context.Context, list *unstructured.UnstructuredList) ([]unstructured.Unstructured, error) {
+	if err := r.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) || k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (r *BtpOperatorReconciler) saveDeprovisioningPreview(ctx context.Context, cr *v1alpha1.BtpOperator, preview *deprovisioningPreview) error {
+	content, err := yaml.Marshal(preview)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deprovisioningPreviewConfigMap,
+			Namespace: cr.GetNamespace(),
+		},
+	}
+	_, err = ctrlutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{deprovisioningPreviewKey: string(content)}
+		return ctrlutil.SetControllerReference(cr, cm, r.Scheme)
+	})
+
+	return err
+}
+
+// bindingSecretName returns the name of the Secret generated for the Service Binding
+func bindingSecretName(binding *unstructured.Unstructured) string {
+	secretName, found, err := unstructured.NestedString(binding.Object, "spec", "secretName")
+	if err != nil || !found || secretName == "" {
+		return binding.GetName()
+	}
+	return secretName
+}
+
+func toPreviewResource(u *unstructured.Unstructured) previewResource {
+	return previewResource{Kind: u.GetKind(), Namespace: u.GetNamespace(), Name: u.GetName()}
+}
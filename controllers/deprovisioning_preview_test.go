@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandleDeprovisioningPreview(t *testing.T) {
+	// given
+	s := readinessTestScheme(t)
+	require.NoError(t, v1alpha1.AddToScheme(s))
+
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{
+		Name:        "preview",
+		Namespace:   "default",
+		UID:         "preview-uid",
+		Annotations: map[string]string{v1alpha1.DeprovisioningPreviewAnnotation: "true"},
+	}}
+	binding := testUnstructured(bindingGvk, "test", "sb")
+	require.NoError(t, unstructured.SetNestedField(binding.Object, "sb-secret", "spec", "secretName"))
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		cr,
+		binding,
+		testUnstructured(instanceGvk, "test", "si"),
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: kymaNamespace}},
+		&admissionregistrationv1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: mutatingWebhookName}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "managed", Namespace: kymaNamespace, Labels: managedByLabelFilter}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: kymaNamespace}},
+	).Build()
+	r := NewBtpOperatorReconciler(c, s)
+	useModuleResources(t, r, "configmap.yml")
+
+	// when
+	err := r.handleDeprovisioningPreview(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+
+	updatedCr := &v1alpha1.BtpOperator{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cr), updatedCr))
+	assert.NotContains(t, updatedCr.GetAnnotations(), v1alpha1.DeprovisioningPreviewAnnotation)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: deprovisioningPreviewConfigMap, Namespace: cr.Namespace}, cm))
+	require.Len(t, cm.OwnerReferences, 1)
+	assert.Equal(t, cr.UID, cm.OwnerReferences[0].UID)
+
+	preview := &deprovisioningPreview{}
+	require.NoError(t, yaml.Unmarshal([]byte(cm.Data[deprovisioningPreviewKey]), preview))
+	assert.Equal(t, v1alpha1.DeletionPolicyDelete, preview.DeletionPolicy)
+	assert.Equal(t, []previewServiceBinding{{Namespace: "test", Name: "sb", Secret: "sb-secret"}}, preview.ServiceBindings)
+	assert.Equal(t, []previewResource{{Kind: btpOperatorServiceInstance, Namespace: "test", Name: "si"}}, preview.ServiceInstances)
+	assert.Equal(t, &previewResource{Kind: deploymentKind, Namespace: kymaNamespace, Name: deploymentName}, preview.Deployment)
+	assert.Equal(t, []previewResource{{Kind: mutatingWebhookKind, Name: mutatingWebhookName}}, preview.Webhooks)
+	assert.Equal(t, []previewResource{{Kind: configMapKind, Namespace: kymaNamespace, Name: "managed"}}, preview.ModuleResources)
+}
+
+func TestDeprovisioningPreviewWithOrphanPolicy(t *testing.T) {
+	// given
+	s := readinessTestScheme(t)
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cr := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{DeletionPolicy: v1alpha1.DeletionPolicyOrphan}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		testUnstructured(instanceGvk, "test", "si"),
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: kymaNamespace}},
+	).Build()
+	r := NewBtpOperatorReconciler(c, s)
+	useModuleResources(t, r, "configmap.yml")
+
+	// when
+	preview, err := r.getDeprovisioningPreview(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, preview.ServiceInstances)
+	assert.Empty(t, preview.ServiceBindings)
+	assert.Nil(t, preview.Deployment)
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const failedConditionType = "Failed"
+
+// serviceResourcesCount holds the number of Service Instances and Service Bindings in a namespace
+type serviceResourcesCount struct {
+	instances int
+	bindings  int
+}
+
+// startDeprovisioningProgress sets the hard delete start time and the deadline at which soft delete starts
+func startDeprovisioningProgress(cr *v1alpha1.BtpOperator, start time.Time, hardDeleteTimeout time.Duration) {
+	if cr.Status.Deprovisioning == nil {
+		cr.Status.Deprovisioning = &v1alpha1.DeprovisioningStatus{}
+	}
+	cr.Status.Deprovisioning.StartTime = &metav1.Time{Time: start}
+	cr.Status.Deprovisioning.SoftDeleteDeadline = &metav1.Time{Time: start.Add(hardDeleteTimeout)}
+}
+
+// setDeprovisioningProgress sets the remaining Service Instances and Service Bindings in the deprovisioning status
+// and the HardDeleting condition message
+func setDeprovisioningProgress(cr *v1alpha1.BtpOperator, counts map[string]*serviceResourcesCount, stuck []v1alpha1.StuckServiceResource) {
+	progress := cr.Status.Deprovisioning
+	progress.Elapsed = time.Since(progress.StartTime.Time).Round(time.Second).String()
+	progress.Remaining = toRemainingServiceResources(counts)
+	progress.Stuck = stuck
+
+	instances, bindings := 0, 0
+	for _, remaining := range progress.Remaining {
+		instances += remaining.ServiceInstances
+		bindings += remaining.ServiceBindings
+	}
+	deprovisioningRemainingResourcesGauge.WithLabelValues(btpOperatorServiceInstance).Set(float64(instances))
+	deprovisioningRemainingResourcesGauge.WithLabelValues(btpOperatorServiceBinding).Set(float64(bindings))
+
+	SetStatusCondition(&cr.Status.Conditions, *ConditionFromExistingReason(HardDeleting, hardDeleteProgressMessage(progress, instances, bindings)))
+}
+
+// listRemainingServiceResources returns the number of Service Instances and Service Bindings per namespace
+// and the ones which report a failure
+func (r *BtpOperatorReconciler) listRemainingServiceResources(ctx context.Context) (map[string]*serviceResourcesCount, []v1alpha1.StuckServiceResource, error) {
+	counts := make(map[string]*serviceResourcesCount)
+	stuck := make([]v1alpha1.StuckServiceResource, 0)
+	for _, gvk := range []schema.GroupVersionKind{instanceGvk, bindingGvk} {
+		list := r.GvkToList(gvk)
+		if err := r.List(ctx, list); err != nil {
+			if meta.IsNoMatchError(err) || k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			count, ok := counts[item.GetNamespace()]
+			if !ok {
+				count = &serviceResourcesCount{}
+				counts[item.GetNamespace()] = count
+			}
+			if gvk.Kind == btpOperatorServiceBinding {
+				count.bindings++
+			} else {
+				count.instances++
+			}
+			if failure := failedCondition(item); failure != nil {
+				stuck = append(stuck, v1alpha1.StuckServiceResource{
+					Kind:      gvk.Kind,
+					Namespace: item.GetNamespace(),
+					Name:      item.GetName(),
+					Reason:    failure.Reason,
+					Message:   failure.Message,
+				})
+			}
+		}
+	}
+
+	return counts, stuck, nil
+}
+
+// failedCondition returns the Failed condition of a Service Instance or Service Binding if its status is True
+func failedCondition(u *unstructured.Unstructured) *metav1.Condition {
+	conditions, found, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == failedConditionType && condition["status"] == string(metav1.ConditionTrue) {
+			reason, _ := condition["reason"].(string)
+			message, _ := condition["message"].(string)
+			return &metav1.Condition{Type: failedConditionType, Status: metav1.ConditionTrue, Reason: reason, Message: message}
+		}
+	}
+	return nil
+}
+
+func toRemainingServiceResources(counts map[string]*serviceResourcesCount) []v1alpha1.RemainingServiceResources {
+	remaining := make([]v1alpha1.RemainingServiceResources, 0, len(counts))
+	for namespace, count := range counts {
+		remaining = append(remaining, v1alpha1.RemainingServiceResources{
+			Namespace:        namespace,
+			ServiceInstances: count.instances,
+			ServiceBindings:  count.bindings,
+		})
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].Namespace < remaining[j].Namespace
+	})
+	return remaining
+}
+
+func hardDeleteProgressMessage(progress *v1alpha1.DeprovisioningStatus, instances, bindings int) string {
+	return fmt.Sprintf("Hard delete in progress for %s, %d Service Instance(s) and %d Service Binding(s) remaining in %d namespace(s), "+
+		"%d failing, soft delete starts at %s", progress.Elapsed, instances, bindings, len(progress.Remaining), len(progress.Stuck),
+		progress.SoftDeleteDeadline.UTC().Format(time.RFC3339))
+}
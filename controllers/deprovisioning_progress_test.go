@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func deprovisioningTestScheme(t *testing.T) *runtime.Scheme {
+	s := readinessTestScheme(t)
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	return s
+}
+
+func deletingBtpOperator(phase v1alpha1.DeprovisioningPhase) *v1alpha1.BtpOperator {
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{
+		Name:       "deprovisioning",
+		Namespace:  "default",
+		Finalizers: []string{deletionFinalizer},
+	}}
+	cr.Status.State = types.StateDeleting
+	cr.Status.Conditions = []*metav1.Condition{ConditionFromExistingReason(HardDeleting, "BtpOperator is to be deleted")}
+	if phase != "" {
+		cr.Status.Deprovisioning = &v1alpha1.DeprovisioningStatus{Phase: phase}
+	}
+	return cr
+}
+
+func TestCheckHardDeleteReportsProgress(t *testing.T) {
+	// given
+	s := deprovisioningTestScheme(t)
+	failingInstance := testUnstructured(instanceGvk, "test", "failing")
+	require.NoError(t, unstructured.SetNestedSlice(failingInstance.Object, []interface{}{
+		map[string]interface{}{"type": "Succeeded", "status": "False", "reason": "DeleteInProgress"},
+		map[string]interface{}{"type": "Failed", "status": "True", "reason": "DeleteFailed", "message": "instance is in use"},
+	}, "status", "conditions"))
+	cr := deletingBtpOperator(v1alpha1.DeprovisioningHardDeleteStarted)
+	start := time.Now().Add(-time.Minute)
+	startDeprovisioningProgress(cr, start, DefaultConfig().HardDeleteTimeout)
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		cr,
+		failingInstance,
+		testUnstructured(instanceGvk, "default", "si"),
+		testUnstructured(bindingGvk, "test", "sb"),
+	).Build()
+	r := NewBtpOperatorReconciler(c, s)
+	defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+
+	// when
+	removed, result, err := r.handleDeprovisioning(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.Equal(t, DefaultConfig().HardDeleteCheckInterval, result.RequeueAfter)
+
+	updatedCr := &v1alpha1.BtpOperator{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cr), updatedCr))
+	progress := updatedCr.Status.Deprovisioning
+	require.NotNil(t, progress)
+	assert.Equal(t, v1alpha1.DeprovisioningHardDeleteStarted, progress.Phase)
+	assert.Equal(t, start.Unix(), progress.StartTime.Unix())
+	assert.Equal(t, start.Add(DefaultConfig().HardDeleteTimeout).Unix(), progress.SoftDeleteDeadline.Unix())
+	assert.NotEmpty(t, progress.Elapsed)
+	assert.Equal(t, []v1alpha1.RemainingServiceResources{
+		{Namespace: "default", ServiceInstances: 1, ServiceBindings: 0},
+		{Namespace: "test", ServiceInstances: 1, ServiceBindings: 1},
+	}, progress.Remaining)
+	assert.Equal(t, []v1alpha1.StuckServiceResource{{
+		Kind:      btpOperatorServiceInstance,
+		Namespace: "test",
+		Name:      "failing",
+		Reason:    "DeleteFailed",
+		Message:   "instance is in use",
+	}}, progress.Stuck)
+	assert.Equal(t, 2.0, testutil.ToFloat64(deprovisioningRemainingResourcesGauge.WithLabelValues(btpOperatorServiceInstance)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(deprovisioningRemainingResourcesGauge.WithLabelValues(btpOperatorServiceBinding)))
+
+	condition := FindStatusCondition(updatedCr.Status.Conditions, ReadyType)
+	require.NotNil(t, condition)
+	assert.Equal(t, string(HardDeleting), condition.Reason)
+	assert.Contains(t, condition.Message, "2 Service Instance(s) and 1 Service Binding(s) remaining in 2 namespace(s), 1 failing")
+	assert.Contains(t, condition.Message, "soft delete starts at "+start.Add(DefaultConfig().HardDeleteTimeout).UTC().Format(time.RFC3339))
+}
+
+func TestHandleDeprovisioningPhases(t *testing.T) {
+	tests := []struct {
+		name            string
+		phase           v1alpha1.DeprovisioningPhase
+		policy          v1alpha1.DeletionPolicy
+		hardDeleteStart time.Time
+		objects         []client.Object
+		expectedRemoved bool
+		expectedPhase   v1alpha1.DeprovisioningPhase
+		expectedReason  Reason
+	}{
+		{
+			name:            "hard delete is started",
+			expectedPhase:   v1alpha1.DeprovisioningHardDeleteStarted,
+			expectedReason:  HardDeleting,
+			expectedRemoved: false,
+		},
+		{
+			name:            "soft delete is started with SoftDeleteOnly policy",
+			policy:          v1alpha1.DeletionPolicySoftDeleteOnly,
+			expectedPhase:   v1alpha1.DeprovisioningSoftDeleteStarted,
+			expectedReason:  SoftDeleting,
+			expectedRemoved: false,
+		},
+		{
+			name:            "soft delete is started after the hard delete deadline",
+			phase:           v1alpha1.DeprovisioningHardDeleteStarted,
+			hardDeleteStart: time.Now().Add(-2 * DefaultConfig().HardDeleteTimeout),
+			objects:         []client.Object{testUnstructured(instanceGvk, "test", "si")},
+			expectedPhase:   v1alpha1.DeprovisioningSoftDeleteStarted,
+			expectedReason:  SoftDeleting,
+			expectedRemoved: false,
+		},
+		{
+			name:            "module resources are removed after hard delete",
+			phase:           v1alpha1.DeprovisioningHardDeleteStarted,
+			hardDeleteStart: time.Now(),
+			expectedPhase:   v1alpha1.DeprovisioningResourcesRemoved,
+			expectedReason:  HardDeleting,
+			expectedRemoved: true,
+		},
+		{
+			name:            "module resources are removed after soft delete",
+			phase:           v1alpha1.DeprovisioningSoftDeleteStarted,
+			expectedPhase:   v1alpha1.DeprovisioningResourcesRemoved,
+			expectedReason:  HardDeleting,
+			expectedRemoved: true,
+		},
+		{
+			name:            "removed resources are not removed again",
+			phase:           v1alpha1.DeprovisioningResourcesRemoved,
+			expectedPhase:   v1alpha1.DeprovisioningResourcesRemoved,
+			expectedReason:  HardDeleting,
+			expectedRemoved: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			s := deprovisioningTestScheme(t)
+			cr := deletingBtpOperator(tt.phase)
+			cr.Spec.DeletionPolicy = tt.policy
+			if !tt.hardDeleteStart.IsZero() {
+				startDeprovisioningProgress(cr, tt.hardDeleteStart, DefaultConfig().HardDeleteTimeout)
+			}
+			objects := append([]client.Object{cr}, tt.objects...)
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+			r := NewBtpOperatorReconciler(c, s)
+			useModuleResources(t, r, "configmap.yml")
+			defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+
+			// when
+			removed, _, err := r.handleDeprovisioning(context.Background(), cr)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedRemoved, removed)
+			updatedCr := &v1alpha1.BtpOperator{}
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cr), updatedCr))
+			require.NotNil(t, updatedCr.Status.Deprovisioning)
+			assert.Equal(t, tt.expectedPhase, updatedCr.Status.Deprovisioning.Phase)
+			condition := FindStatusCondition(updatedCr.Status.Conditions, ReadyType)
+			require.NotNil(t, condition)
+			assert.Equal(t, string(tt.expectedReason), condition.Reason)
+		})
+	}
+}
+
+func TestHandleDeletingStateRemovesFinalizerOnceResourcesAreRemoved(t *testing.T) {
+	// given
+	s := deprovisioningTestScheme(t)
+	cr := deletingBtpOperator(v1alpha1.DeprovisioningResourcesRemoved)
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(cr).Build()
+	r := NewBtpOperatorReconciler(c, s)
+
+	// when
+	result, err := r.HandleDeletingState(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+	assert.Zero(t, result)
+	updatedCr := &v1alpha1.BtpOperator{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(cr), updatedCr))
+	assert.Empty(t, updatedCr.GetFinalizers())
+}
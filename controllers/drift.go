@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// DriftCorrected is the reason of the event emitted when module resources changed outside of btp-manager are applied again
+const DriftCorrected Reason = "DriftCorrected"
+
+const (
+	driftChanged = "changed"
+	driftDeleted = "deleted"
+)
+
+// drift is a change of a module resource made outside of btp-manager
+type drift struct {
+	change          string
+	resourceVersion string
+}
+
+// managedResourceTypes are the kinds of module resources watched for drift. Except for Secrets and ConfigMaps,
+// the cache holds only the objects labeled as managed by btp-manager, see ManagedResourcesCacheSelectors.
+var managedResourceTypes = []client.Object{
+	&appsv1.Deployment{},
+	&corev1.ConfigMap{},
+	&corev1.Secret{},
+	&corev1.Service{},
+	&rbacv1.ClusterRole{},
+	&rbacv1.ClusterRoleBinding{},
+	&rbacv1.Role{},
+	&rbacv1.RoleBinding{},
+	&admissionregistrationv1.MutatingWebhookConfiguration{},
+	&admissionregistrationv1.ValidatingWebhookConfiguration{},
+	&apiextensionsv1.CustomResourceDefinition{},
+}
+
+// ManagedResourcesCacheSelectors limits the cache of the kinds watched for drift to the objects labeled as managed by btp-manager.
+// Secrets and ConfigMaps are cached without a selector, because the credentials Secrets and the config ConfigMap are not labeled.
+func ManagedResourcesCacheSelectors() cache.SelectorsByObject {
+	selector := cache.ObjectSelector{Label: labels.SelectorFromSet(labels.Set{managedByLabelKey: operatorName})}
+	selectors := make(cache.SelectorsByObject)
+	for _, object := range managedResourceTypes {
+		switch object.(type) {
+		case *corev1.Secret, *corev1.ConfigMap:
+			continue
+		}
+		selectors[object] = selector
+	}
+	return selectors
+}
+
+// watchManagedResourcePredicates passes updates and deletions of module resources, ignoring updates of the status
+// and the writes of btp-manager itself, recognized by their resource version. Creations are ignored, so the initial listing
+// does not enqueue the CR.
+func (r *BtpOperatorReconciler) watchManagedResourcePredicates() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool { return false },
+		DeleteFunc: func(e event.DeleteEvent) bool { return isManaged(e.Object) && !r.isInventory(e.Object) },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !isManaged(e.ObjectOld) && !isManaged(e.ObjectNew) {
+				return false
+			}
+			return !r.isInventory(e.ObjectNew) && !r.isOwnWrite(e.ObjectNew) && specChanged(e.ObjectOld, e.ObjectNew)
+		},
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}
+}
+
+// managedResourceDriftHandler records the drifted module resource and enqueues the oldest BtpOperator CR
+func (r *BtpOperatorReconciler) managedResourceDriftHandler() handler.Funcs {
+	enqueue := func(o client.Object, change string, q workqueue.RateLimitingInterface) {
+		r.recordDrift(o, change)
+		for _, req := range r.enqueueOldestBtpOperator() {
+			q.Add(req)
+		}
+	}
+	return handler.Funcs{
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) { enqueue(e.ObjectNew, driftChanged, q) },
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) { enqueue(e.Object, driftDeleted, q) },
+	}
+}
+
+func (r *BtpOperatorReconciler) recordDrift(o client.Object, change string) {
+	key := r.objectKey(o)
+
+	r.driftLock.Lock()
+	defer r.driftLock.Unlock()
+	if r.drifted == nil {
+		r.drifted = make(map[string]drift)
+	}
+	r.drifted[key] = drift{change: change, resourceVersion: o.GetResourceVersion()}
+}
+
+// recordOwnWrite remembers the resource version of the module resource applied by btp-manager
+func (r *BtpOperatorReconciler) recordOwnWrite(u *unstructured.Unstructured) {
+	r.driftLock.Lock()
+	defer r.driftLock.Unlock()
+	if r.ownWrites == nil {
+		r.ownWrites = make(map[string]string)
+	}
+	r.ownWrites[resourceKey(u)] = u.GetResourceVersion()
+}
+
+// isOwnWrite tells whether the object is in the version written by the latest apply of btp-manager
+func (r *BtpOperatorReconciler) isOwnWrite(o client.Object) bool {
+	key := r.objectKey(o)
+
+	r.driftLock.Lock()
+	defer r.driftLock.Unlock()
+	resourceVersion, ok := r.ownWrites[key]
+	return ok && resourceVersion == o.GetResourceVersion()
+}
+
+// takeDrift returns the module resources which drifted since the last call. A change whose watch event arrived before
+// the apply of btp-manager returned its resource version is recognized here as an own write and left out.
+func (r *BtpOperatorReconciler) takeDrift() map[string]string {
+	r.driftLock.Lock()
+	defer r.driftLock.Unlock()
+	drifted := make(map[string]string)
+	for key, d := range r.drifted {
+		if d.change == driftChanged && d.resourceVersion == r.ownWrites[key] {
+			continue
+		}
+		drifted[key] = d.change
+	}
+	r.drifted = nil
+	return drifted
+}
+
+// objectKey returns the resourceKey of the object, whose TypeMeta is usually empty in watch events
+func (r *BtpOperatorReconciler) objectKey(o client.Object) string {
+	u := &unstructured.Unstructured{}
+	if gvk, err := apiutil.GVKForObject(o, r.Scheme); err == nil {
+		u.SetGroupVersionKind(gvk)
+	}
+	u.SetNamespace(o.GetNamespace())
+	u.SetName(o.GetName())
+	return resourceKey(u)
+}
+
+// reportDriftCorrected emits the DriftCorrected event listing the drifted module resources which were applied again.
+// Drifted resources which are not applied anymore, for example pruned ones, are not reported.
+func (r *BtpOperatorReconciler) reportDriftCorrected(cr *v1alpha1.BtpOperator, applied []*unstructured.Unstructured) {
+	drifted := r.takeDrift()
+	if len(drifted) == 0 {
+		return
+	}
+	corrected := make([]string, 0)
+	for _, u := range applied {
+		change, ok := drifted[resourceKey(u)]
+		if !ok {
+			continue
+		}
+		driftCorrectedCounter.WithLabelValues(u.GetKind()).Inc()
+		corrected = append(corrected, fmt.Sprintf("%s (%s)", resourceName(u), change))
+	}
+	if len(corrected) == 0 {
+		return
+	}
+	sort.Strings(corrected)
+	r.recordEvent(cr, corev1.EventTypeNormal, DriftCorrected,
+		fmt.Sprintf("Applied module resources changed outside of btp-manager: %s", strings.Join(corrected, ", ")))
+}
+
+func isManaged(o client.Object) bool {
+	return o.GetLabels()[managedByLabelKey] == operatorName
+}
+
+// isInventory tells whether the object is the inventory ConfigMap or the last-known-good Secret, which btp-manager updates itself
+func (r *BtpOperatorReconciler) isInventory(o client.Object) bool {
+	if o.GetNamespace() != r.config().ChartNamespace {
+		return false
+	}
+	switch o.(type) {
+	case *corev1.ConfigMap:
+		return o.GetName() == btpManagerConfigMap
+	case *corev1.Secret:
+		return o.GetName() == lastKnownGoodSecret
+	}
+	return false
+}
+
+// specChanged tells whether the update changed more than the status. The generation tracks the changes
+// of objects with the status subresource, for the other objects everything but the metadata and status is compared.
+func specChanged(oldObject, newObject client.Object) bool {
+	if !equality.Semantic.DeepEqual(oldObject.GetLabels(), newObject.GetLabels()) {
+		return true
+	}
+	if newObject.GetGeneration() > 0 {
+		return oldObject.GetGeneration() != newObject.GetGeneration()
+	}
+	oldContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldObject)
+	if err != nil {
+		return true
+	}
+	newContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newObject)
+	if err != nil {
+		return true
+	}
+	for _, field := range []string{"metadata", "status"} {
+		delete(oldContent, field)
+		delete(newContent, field)
+	}
+	return !equality.Semantic.DeepEqual(oldContent, newContent)
+}
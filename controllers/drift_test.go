@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func driftTestDeployment(generation int64, resourceVersion string) *appsv1.Deployment {
+	return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:            deploymentName,
+		Namespace:       kymaNamespace,
+		Generation:      generation,
+		ResourceVersion: resourceVersion,
+		Labels:          map[string]string{managedByLabelKey: operatorName},
+	}}
+}
+
+func appliedDeployment(resourceVersion string) *unstructured.Unstructured {
+	u := testUnstructured(appsv1.SchemeGroupVersion.WithKind(deploymentKind), kymaNamespace, deploymentName)
+	u.SetResourceVersion(resourceVersion)
+	return u
+}
+
+func TestWatchManagedResourcePredicates(t *testing.T) {
+	r := NewBtpOperatorReconciler(nil, clientgoscheme.Scheme)
+	r.recordOwnWrite(appliedDeployment("3"))
+	p := r.watchManagedResourcePredicates()
+	managedConfigMap := func(data string, name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: kymaNamespace, Labels: map[string]string{managedByLabelKey: operatorName}},
+			Data:       map[string]string{"key": data},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		old, new client.Object
+		expected bool
+	}{
+		{
+			name:     "status update",
+			old:      driftTestDeployment(1, "1"),
+			new:      driftTestDeployment(1, "2"),
+			expected: false,
+		},
+		{
+			name:     "spec changed by btp-manager",
+			old:      driftTestDeployment(1, "2"),
+			new:      driftTestDeployment(2, "3"),
+			expected: false,
+		},
+		{
+			name:     "spec changed by user",
+			old:      driftTestDeployment(2, "3"),
+			new:      driftTestDeployment(3, "4"),
+			expected: true,
+		},
+		{
+			name:     "data of ConfigMap changed",
+			old:      managedConfigMap("old", btpServiceOperatorConfigMap),
+			new:      managedConfigMap("new", btpServiceOperatorConfigMap),
+			expected: true,
+		},
+		{
+			name:     "only metadata of ConfigMap changed",
+			old:      managedConfigMap("old", btpServiceOperatorConfigMap),
+			new:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: btpServiceOperatorConfigMap, Namespace: kymaNamespace, Labels: map[string]string{managedByLabelKey: operatorName}, Annotations: map[string]string{"a": "b"}}, Data: map[string]string{"key": "old"}},
+			expected: false,
+		},
+		{
+			name:     "inventory ConfigMap",
+			old:      managedConfigMap("old", btpManagerConfigMap),
+			new:      managedConfigMap("new", btpManagerConfigMap),
+			expected: false,
+		},
+		{
+			name:     "not managed resource",
+			old:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other"}, Data: map[string]string{"key": "old"}},
+			new:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other"}, Data: map[string]string{"key": "new"}},
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, p.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}))
+		})
+	}
+
+	assert.False(t, p.Create(event.CreateEvent{Object: driftTestDeployment(1, "1")}))
+	assert.True(t, p.Delete(event.DeleteEvent{Object: driftTestDeployment(2, "3")}))
+	assert.False(t, p.Delete(event.DeleteEvent{Object: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "other"}}}))
+}
+
+func TestManagedResourcesCacheSelectors(t *testing.T) {
+	selectors := ManagedResourcesCacheSelectors()
+
+	assert.Len(t, selectors, len(managedResourceTypes)-2)
+	for object, selector := range selectors {
+		switch object.(type) {
+		case *corev1.Secret, *corev1.ConfigMap:
+			t.Errorf("%T objects must be cached unfiltered for the credentials and config lookups", object)
+		}
+		assert.True(t, selector.Label.Matches(labels.Set{managedByLabelKey: operatorName}), "%T", object)
+		assert.False(t, selector.Label.Matches(labels.Set{}), "%T", object)
+	}
+}
+
+func TestReportDriftCorrected(t *testing.T) {
+	// given
+	recorder := record.NewFakeRecorder(2)
+	r := NewBtpOperatorReconciler(nil, clientgoscheme.Scheme)
+	r.Recorder = recorder
+	r.recordDrift(driftTestDeployment(1, "1"), driftDeleted)
+	r.recordDrift(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "pruned", Namespace: kymaNamespace}}, driftChanged)
+	corrected := testutil.ToFloat64(driftCorrectedCounter.WithLabelValues(deploymentKind))
+	applied := testUnstructured(appsv1.SchemeGroupVersion.WithKind(deploymentKind), kymaNamespace, deploymentName)
+
+	// when
+	r.reportDriftCorrected(&v1alpha1.BtpOperator{}, []*unstructured.Unstructured{applied})
+
+	// then
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, string(DriftCorrected))
+	assert.Contains(t, event, "Deployment kyma-system/sap-btp-operator-controller-manager (deleted)")
+	assert.NotContains(t, event, "pruned")
+	assert.Equal(t, corrected+1, testutil.ToFloat64(driftCorrectedCounter.WithLabelValues(deploymentKind)))
+	assert.Empty(t, r.takeDrift())
+
+	// when
+	r.reportDriftCorrected(&v1alpha1.BtpOperator{}, []*unstructured.Unstructured{applied})
+
+	// then
+	assert.Empty(t, recorder.Events)
+}
+
+func TestTakeDriftIgnoresOwnWrites(t *testing.T) {
+	// given
+	r := NewBtpOperatorReconciler(nil, clientgoscheme.Scheme)
+	r.recordOwnWrite(appliedDeployment("2"))
+	// the watch event of the apply arrives before the apply returns its resource version
+	r.recordDrift(driftTestDeployment(2, "3"), driftChanged)
+	r.recordOwnWrite(appliedDeployment("3"))
+	r.recordDrift(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: btpServiceOperatorConfigMap, Namespace: kymaNamespace, ResourceVersion: "5"}}, driftChanged)
+
+	// when
+	drifted := r.takeDrift()
+
+	// then
+	assert.Equal(t, map[string]string{"/ConfigMap/kyma-system/" + btpServiceOperatorConfigMap: driftChanged}, drifted)
+
+	// when
+	r.recordDrift(driftTestDeployment(2, "3"), driftDeleted)
+
+	// then
+	assert.Len(t, r.takeDrift(), 1, "deletion is drift even if btp-manager wrote the last version")
+}
@@ -1,5 +1,7 @@
 package controllers
 
+import "errors"
+
 type ErrorWithReason struct {
 	message string
 	reason  Reason
@@ -15,3 +17,12 @@ func NewErrorWithReason(reason Reason, message string) *ErrorWithReason {
 func (e *ErrorWithReason) Error() string {
 	return e.message
 }
+
+// reasonOf returns the reason of the ErrorWithReason wrapped in the error, or the fallback reason
+func reasonOf(err error, fallback Reason) Reason {
+	var errWithReason *ErrorWithReason
+	if errors.As(err, &errWithReason) {
+		return errWithReason.reason
+	}
+	return fallback
+}
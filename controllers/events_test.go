@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateBtpOperatorStatusRecordsEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		state         types.State
+		reason        Reason
+		message       string
+		expectedEvent string
+	}{
+		{
+			name:          "state change",
+			state:         types.StateProcessing,
+			reason:        Initialized,
+			message:       "Initialized",
+			expectedEvent: "Normal Initialized Initialized",
+		},
+		{
+			name:          "secret validation failure",
+			state:         types.StateError,
+			reason:        InvalidSecret,
+			message:       "missing clientid key",
+			expectedEvent: "Warning InvalidSecret missing clientid key",
+		},
+		{
+			name:          "fallback to soft delete",
+			state:         types.StateDeleting,
+			reason:        SoftDeleting,
+			message:       "Hard delete failed, being soft deleted",
+			expectedEvent: "Warning SoftDeleting Hard delete failed, being soft deleted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			s := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(s))
+			require.NoError(t, v1alpha1.AddToScheme(s))
+			cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "events", Namespace: "default"}}
+			r := NewBtpOperatorReconciler(fake.NewClientBuilder().WithScheme(s).WithObjects(cr).Build(), s)
+			recorder := record.NewFakeRecorder(1)
+			r.Recorder = recorder
+			defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+
+			// when
+			err := r.UpdateBtpOperatorStatus(context.Background(), cr, tt.state, tt.reason, tt.message)
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, recorder.Events, 1)
+			assert.Equal(t, tt.expectedEvent, <-recorder.Events)
+		})
+	}
+}
+
+func TestRecordEventWithoutRecorder(t *testing.T) {
+	r := &BtpOperatorReconciler{}
+
+	assert.NotPanics(t, func() {
+		r.recordEvent(&v1alpha1.BtpOperator{}, "Normal", Initialized, "Initialized")
+	})
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kyma-project/btp-manager/internal/gvksutils"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// appliedResourcesInventory is persisted in the btpManagerConfigMap and holds the GVKs of module resources applied
+// by the current and the previous chart version. Together with the managed-by label, the GVKs are enough to find
+// every module resource in the cluster and prune the ones which are no longer part of the module.
+type appliedResourcesInventory struct {
+	oldChartVersion     string
+	oldGvks             []schema.GroupVersionKind
+	currentChartVersion string
+	currentGvks         []schema.GroupVersionKind
+}
+
+func (r *BtpOperatorReconciler) getInventory(ctx context.Context) (*appliedResourcesInventory, error) {
+	cm := &corev1.ConfigMap{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.config().ChartNamespace, Name: btpManagerConfigMap}, cm); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return &appliedResourcesInventory{}, nil
+		}
+		return nil, err
+	}
+
+	oldGvks, err := gvksutils.StrToGvks(cm.Data[oldGvksKey])
+	if err != nil {
+		return nil, fmt.Errorf("while parsing %s from %s ConfigMap: %w", oldGvksKey, btpManagerConfigMap, err)
+	}
+	currentGvks, err := gvksutils.StrToGvks(cm.Data[currentGvksKey])
+	if err != nil {
+		return nil, fmt.Errorf("while parsing %s from %s ConfigMap: %w", currentGvksKey, btpManagerConfigMap, err)
+	}
+
+	return &appliedResourcesInventory{
+		oldChartVersion:     cm.Data[oldChartVersionKey],
+		oldGvks:             oldGvks,
+		currentChartVersion: cm.Data[currentCharVersionKey],
+		currentGvks:         currentGvks,
+	}, nil
+}
+
+func (r *BtpOperatorReconciler) saveInventory(ctx context.Context, inventory *appliedResourcesInventory) error {
+	oldGvks, err := gvksutils.GvksToStr(inventory.oldGvks)
+	if err != nil {
+		return err
+	}
+	currentGvks, err := gvksutils.GvksToStr(inventory.currentGvks)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      btpManagerConfigMap,
+			Namespace: r.config().ChartNamespace,
+		},
+	}
+	_, err = ctrlutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = make(map[string]string)
+		}
+		cm.Labels[managedByLabelKey] = operatorName
+		cm.Data = map[string]string{
+			oldChartVersionKey:    inventory.oldChartVersion,
+			oldGvksKey:            oldGvks,
+			currentCharVersionKey: inventory.currentChartVersion,
+			currentGvksKey:        currentGvks,
+		}
+		return nil
+	})
+
+	return err
+}
+
+// pruneResources deletes module resources which were applied before but are not part of the applied resources anymore
+// and records the applied resources in the inventory
+func (r *BtpOperatorReconciler) pruneResources(ctx context.Context, applied []*unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+
+	chartVer := chartVersionOf(applied)
+
+	inventory, err := r.getInventory(ctx)
+	if err != nil {
+		return fmt.Errorf("while getting applied resources inventory: %w", err)
+	}
+
+	appliedGvks := uniqueGvks(applied)
+	previousGvks := mergeGvks(inventory.oldGvks, inventory.currentGvks)
+	newInventory := &appliedResourcesInventory{
+		oldChartVersion:     inventory.currentChartVersion,
+		oldGvks:             inventory.currentGvks,
+		currentChartVersion: chartVer,
+		currentGvks:         appliedGvks,
+	}
+
+	logger.Info("pruning module resources which are not applied anymore")
+	pruneErr := r.deleteNotAppliedResources(ctx, mergeGvks(previousGvks, appliedGvks), applied)
+	if pruneErr != nil {
+		// keep all previously applied GVKs so that the next reconciliation retries pruning them
+		newInventory.oldGvks = previousGvks
+	}
+
+	if err := r.saveInventory(ctx, newInventory); err != nil {
+		return fmt.Errorf("while saving applied resources inventory: %w", err)
+	}
+
+	return pruneErr
+}
+
+func (r *BtpOperatorReconciler) deleteNotAppliedResources(ctx context.Context, gvks []schema.GroupVersionKind, applied []*unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+
+	appliedKeys := make(map[string]struct{}, len(applied))
+	for _, u := range applied {
+		appliedKeys[resourceKey(u)] = struct{}{}
+	}
+
+	var errs []string
+	for _, gvk := range gvks {
+		mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("failed to get REST mapping of %s: %s", gvk, err))
+			continue
+		}
+		// module resources of namespaced kinds live only in the chart namespace, so labelled objects in other namespaces are left alone
+		listOptions := []client.ListOption{managedByLabelFilter}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			listOptions = append(listOptions, client.InNamespace(r.config().ChartNamespace))
+		}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk)
+		if err := r.List(ctx, list, listOptions...); err != nil {
+			if meta.IsNoMatchError(err) || k8serrors.IsNotFound(err) {
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("failed to list %s: %s", gvk, err))
+			continue
+		}
+		for i := range list.Items {
+			u := &list.Items[i]
+			if _, ok := appliedKeys[resourceKey(u)]; ok || isInventoryConfigMap(u, r.config().ChartNamespace) || isLastKnownGoodSecret(u, r.config().ChartNamespace) {
+				continue
+			}
+			if err := r.Delete(ctx, u); err != nil && !k8serrors.IsNotFound(err) {
+				errs = append(errs, fmt.Sprintf("failed to delete %s %s: %s", u.GetName(), u.GetKind(), err))
+				continue
+			}
+			logger.Info("pruned resource", "name", u.GetName(), "namespace", u.GetNamespace(), "kind", u.GetKind())
+		}
+	}
+
+	if errs != nil {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func isInventoryConfigMap(u *unstructured.Unstructured, namespace string) bool {
+	return u.GetKind() == configMapKind && u.GetName() == btpManagerConfigMap && u.GetNamespace() == namespace
+}
+
+func resourceKey(u *unstructured.Unstructured) string {
+	gvk := u.GroupVersionKind()
+	return fmt.Sprintf("%s/%s/%s/%s", gvk.Group, gvk.Kind, u.GetNamespace(), u.GetName())
+}
+
+func uniqueGvks(us []*unstructured.Unstructured) []schema.GroupVersionKind {
+	gvks := make([]schema.GroupVersionKind, 0)
+	for _, u := range us {
+		gvks = append(gvks, u.GroupVersionKind())
+	}
+	return mergeGvks(gvks)
+}
+
+// mergeGvks returns sorted unique GVKs from the given lists
+func mergeGvks(lists ...[]schema.GroupVersionKind) []schema.GroupVersionKind {
+	unique := make(map[schema.GroupVersionKind]struct{})
+	for _, gvks := range lists {
+		for _, gvk := range gvks {
+			unique[gvk] = struct{}{}
+		}
+	}
+	merged := make([]schema.GroupVersionKind, 0, len(unique))
+	for gvk := range unique {
+		merged = append(merged, gvk)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].String() < merged[j].String()
+	})
+	return merged
+}
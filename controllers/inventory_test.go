@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/internal/gvksutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	secretGvk    = schema.GroupVersionKind{Version: "v1", Kind: secretKind}
+	configMapGvk = schema.GroupVersionKind{Version: "v1", Kind: configMapKind}
+)
+
+func TestPruneResources(t *testing.T) {
+	// given
+	previousGvks, err := gvksutils.GvksToStr([]schema.GroupVersionKind{configMapGvk, secretGvk})
+	require.NoError(t, err)
+	c := fake.NewClientBuilder().WithRESTMapper(testRESTMapper()).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: btpManagerConfigMap, Namespace: kymaNamespace, Labels: managedByLabelFilter},
+			Data: map[string]string{
+				currentCharVersionKey: "v0.2.2",
+				currentGvksKey:        previousGvks,
+			},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "applied", Namespace: kymaNamespace, Labels: managedByLabelFilter}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: kymaNamespace, Labels: managedByLabelFilter}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: kymaNamespace}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "dropped", Namespace: kymaNamespace, Labels: managedByLabelFilter}},
+	).Build()
+	r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+
+	applied := testUnstructured(secretGvk, kymaNamespace, "applied")
+	r.addLabels("v0.2.3", applied)
+
+	// when
+	err = r.pruneResources(context.Background(), []*unstructured.Unstructured{applied})
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, secretExists(t, c, "applied"))
+	assert.True(t, secretExists(t, c, "unmanaged"))
+	assert.False(t, secretExists(t, c, "renamed"))
+	err = c.Get(context.Background(), client.ObjectKey{Name: "dropped", Namespace: kymaNamespace}, &corev1.ConfigMap{})
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	inventory, err := r.getInventory(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &appliedResourcesInventory{
+		oldChartVersion:     "v0.2.2",
+		oldGvks:             []schema.GroupVersionKind{configMapGvk, secretGvk},
+		currentChartVersion: "v0.2.3",
+		currentGvks:         []schema.GroupVersionKind{secretGvk},
+	}, inventory)
+}
+
+func TestPruneResourcesWithoutInventory(t *testing.T) {
+	// given
+	c := fake.NewClientBuilder().WithRESTMapper(testRESTMapper()).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "applied", Namespace: kymaNamespace, Labels: managedByLabelFilter}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: kymaNamespace, Labels: managedByLabelFilter}},
+	).Build()
+	r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+
+	applied := testUnstructured(secretGvk, kymaNamespace, "applied")
+	r.addLabels("v0.2.3", applied)
+
+	// when
+	err := r.pruneResources(context.Background(), []*unstructured.Unstructured{applied})
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, secretExists(t, c, "applied"))
+	assert.False(t, secretExists(t, c, "renamed"))
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: btpManagerConfigMap, Namespace: kymaNamespace}, cm))
+	assert.Equal(t, operatorName, cm.Labels[managedByLabelKey])
+	assert.Equal(t, "v0.2.3", cm.Data[currentCharVersionKey])
+	assert.Empty(t, cm.Data[oldChartVersionKey])
+}
+
+func TestPruneResourcesKeepsObjectsOutsideChartNamespace(t *testing.T) {
+	// given
+	clusterRoleGvk := rbacv1.SchemeGroupVersion.WithKind(clusterRoleKind)
+	previousGvks, err := gvksutils.GvksToStr([]schema.GroupVersionKind{secretGvk, clusterRoleGvk})
+	require.NoError(t, err)
+	c := fake.NewClientBuilder().WithRESTMapper(testRESTMapper()).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: btpManagerConfigMap, Namespace: kymaNamespace, Labels: managedByLabelFilter},
+			Data:       map[string]string{currentCharVersionKey: "v0.2.2", currentGvksKey: previousGvks},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: kymaNamespace, Labels: managedByLabelFilter}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "renamed", Namespace: "other", Labels: managedByLabelFilter}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "renamed", Labels: managedByLabelFilter}},
+	).Build()
+	r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+
+	applied := testUnstructured(secretGvk, kymaNamespace, "applied")
+	r.addLabels("v0.2.3", applied)
+
+	// when
+	err = r.pruneResources(context.Background(), []*unstructured.Unstructured{applied})
+
+	// then
+	require.NoError(t, err)
+	assert.False(t, secretExists(t, c, "renamed"))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "renamed", Namespace: "other"}, &corev1.Secret{}),
+		"labelled objects outside the chart namespace should not be pruned")
+	err = c.Get(context.Background(), client.ObjectKey{Name: "renamed"}, &rbacv1.ClusterRole{})
+	assert.True(t, k8serrors.IsNotFound(err), "cluster-scoped objects should be pruned")
+}
+
+func TestMergeGvks(t *testing.T) {
+	// when
+	merged := mergeGvks(
+		[]schema.GroupVersionKind{secretGvk, configMapGvk},
+		nil,
+		[]schema.GroupVersionKind{configMapGvk, {Group: "apps", Version: "v1", Kind: deploymentKind}},
+	)
+
+	// then
+	assert.Equal(t, []schema.GroupVersionKind{
+		{Version: "v1", Kind: configMapKind},
+		{Version: "v1", Kind: secretKind},
+		{Group: "apps", Version: "v1", Kind: deploymentKind},
+	}, merged)
+}
+
+// testRESTMapper maps the kinds of the client-go scheme, the ones without a namespace as cluster-scoped
+func testRESTMapper() meta.RESTMapper {
+	clusterScoped := map[string]bool{namespaceKind: true, clusterRoleKind: true, clusterRoleBindingKind: true, crdKind: true,
+		mutatingWebhookKind: true, validatingWebhookKind: true, "PriorityClass": true}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for gvk := range clientgoscheme.Scheme.AllKnownTypes() {
+		scope := meta.RESTScopeNamespace
+		if clusterScoped[gvk.Kind] {
+			scope = meta.RESTScopeRoot
+		}
+		mapper.Add(gvk, scope)
+	}
+	return mapper
+}
+
+func secretExists(t *testing.T, c client.Client, name string) bool {
+	err := c.Get(context.Background(), client.ObjectKey{Name: name, Namespace: kymaNamespace}, &corev1.Secret{})
+	if k8serrors.IsNotFound(err) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
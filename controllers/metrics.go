@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "btp_manager"
+
+	hardDeleteMode = "hard"
+	softDeleteMode = "soft"
+	orphanMode     = "orphan"
+
+	resultSuccess = "success"
+	resultFailure = "failure"
+	resultTimeout = "timeout"
+
+	applyOperation     = "apply"
+	pruneOperation     = "prune"
+	readinessOperation = "readiness"
+)
+
+var (
+	btpOperatorStates = []types.State{types.StateProcessing, types.StateDeleting, types.StateReady, types.StateError}
+
+	btpOperatorStateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "btpoperator_state",
+		Help:      "Current state of the BtpOperator CR, 1 for the current state and 0 for the other ones",
+	}, []string{"namespace", "name", "state"})
+
+	reconcileDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of BtpOperator reconciliation by the handled state",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"state"})
+
+	reconcileFailuresCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_failures_total",
+		Help:      "Number of reconciliations which set the BtpOperator CR in Error state by the condition reason",
+	}, []string{"reason"})
+
+	moduleResourcesFailuresCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "module_resources_failures_total",
+		Help:      "Number of failed module resources operations by the operation (apply, prune, readiness)",
+	}, []string{"operation"})
+
+	deprovisioningCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deprovisioning_total",
+		Help:      "Number of deprovisioning attempts by the delete mode (hard, soft, orphan) and the result (success, failure, timeout)",
+	}, []string{"mode", "result"})
+
+	deprovisioningDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "deprovisioning_duration_seconds",
+		Help:      "Duration of deprovisioning by the delete mode (hard, soft, orphan)",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200, 1800},
+	}, []string{"mode"})
+
+	deprovisioningRemainingResourcesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "deprovisioning_remaining_resources",
+		Help:      "Number of Service Instances and Service Bindings remaining in the cluster during deprovisioning",
+	}, []string{"kind"})
+
+	driftCorrectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "drift_corrected_total",
+		Help:      "Number of module resources changed or deleted outside of btp-manager and applied again by the kind",
+	}, []string{"kind"})
+
+	fieldManagerConflictsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "field_manager_conflicts_total",
+		Help:      "Number of module resource fields owned by other field managers by the field manager and the action (forced, refused)",
+	}, []string{"manager", "action"})
+
+	upgradeRollbacksCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "upgrade_rollbacks_total",
+		Help:      "Number of rollbacks to the last-known-good chart version after an upgrade failed readiness by the result (success, failure)",
+	}, []string{"result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		btpOperatorStateGauge,
+		reconcileDurationHistogram,
+		reconcileFailuresCounter,
+		moduleResourcesFailuresCounter,
+		deprovisioningCounter,
+		deprovisioningDurationHistogram,
+		deprovisioningRemainingResourcesGauge,
+		driftCorrectedCounter,
+		fieldManagerConflictsCounter,
+		upgradeRollbacksCounter,
+	)
+}
+
+func recordBtpOperatorState(cr *v1alpha1.BtpOperator) {
+	for _, state := range btpOperatorStates {
+		value := 0.0
+		if cr.Status.State == state {
+			value = 1
+		}
+		btpOperatorStateGauge.WithLabelValues(cr.GetNamespace(), cr.GetName(), string(state)).Set(value)
+	}
+}
+
+func deleteBtpOperatorState(namespace, name string) {
+	btpOperatorStateGauge.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+func observeReconcileDuration(state types.State, start time.Time) {
+	if state == "" {
+		state = "Initial"
+	}
+	reconcileDurationHistogram.WithLabelValues(string(state)).Observe(time.Since(start).Seconds())
+}
+
+func observeDeprovisioning(mode, result string, start time.Time) {
+	deprovisioningCounter.WithLabelValues(mode, result).Inc()
+	deprovisioningDurationHistogram.WithLabelValues(mode).Observe(time.Since(start).Seconds())
+}
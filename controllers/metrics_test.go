@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRecordBtpOperatorState(t *testing.T) {
+	// given
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "metrics-state", Namespace: "default"}}
+	cr.Status.State = types.StateReady
+	defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+
+	// when
+	recordBtpOperatorState(cr)
+
+	// then
+	assert.Equal(t, 1.0, testutil.ToFloat64(btpOperatorStateGauge.WithLabelValues(cr.Namespace, cr.Name, string(types.StateReady))))
+	assert.Equal(t, 0.0, testutil.ToFloat64(btpOperatorStateGauge.WithLabelValues(cr.Namespace, cr.Name, string(types.StateError))))
+
+	// when
+	cr.Status.State = types.StateError
+	recordBtpOperatorState(cr)
+
+	// then
+	assert.Equal(t, 0.0, testutil.ToFloat64(btpOperatorStateGauge.WithLabelValues(cr.Namespace, cr.Name, string(types.StateReady))))
+	assert.Equal(t, 1.0, testutil.ToFloat64(btpOperatorStateGauge.WithLabelValues(cr.Namespace, cr.Name, string(types.StateError))))
+
+	// when
+	deleteBtpOperatorState(cr.Namespace, cr.Name)
+
+	// then
+	assert.Equal(t, 0, testutil.CollectAndCount(btpOperatorStateGauge))
+}
+
+func TestUpdateBtpOperatorStatusCountsFailures(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "metrics-failures", Namespace: "default"}}
+	r := NewBtpOperatorReconciler(fake.NewClientBuilder().WithScheme(s).WithObjects(cr).Build(), s)
+	defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+	failures := testutil.ToFloat64(reconcileFailuresCounter.WithLabelValues(string(MissingSecret)))
+
+	// when
+	require.NoError(t, r.UpdateBtpOperatorStatus(context.Background(), cr, types.StateProcessing, Initialized, "Initialized"))
+	require.NoError(t, r.UpdateBtpOperatorStatus(context.Background(), cr, types.StateError, MissingSecret, "Secret not found"))
+
+	// then
+	assert.Equal(t, failures+1, testutil.ToFloat64(reconcileFailuresCounter.WithLabelValues(string(MissingSecret))))
+	assert.Equal(t, 1.0, testutil.ToFloat64(btpOperatorStateGauge.WithLabelValues(cr.Namespace, cr.Name, string(types.StateError))))
+}
+
+func TestUpdateBtpOperatorStatusFailureIsNotRecorded(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "metrics-conflict", Namespace: "default"}}
+	r := NewBtpOperatorReconciler(fake.NewClientBuilder().WithScheme(s).WithObjects(cr).Build(), s)
+	recorder := record.NewFakeRecorder(1)
+	r.Recorder = recorder
+	defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+	failures := testutil.ToFloat64(reconcileFailuresCounter.WithLabelValues(string(InvalidSecret)))
+	stale := cr.DeepCopy()
+	stale.ResourceVersion = "1"
+
+	// when
+	err := r.UpdateBtpOperatorStatus(context.Background(), stale, types.StateError, InvalidSecret, "missing clientid key")
+
+	// then
+	require.True(t, k8serrors.IsConflict(err))
+	assert.Equal(t, failures, testutil.ToFloat64(reconcileFailuresCounter.WithLabelValues(string(InvalidSecret))))
+	assert.Empty(t, recorder.Events)
+	assert.Equal(t, 0.0, testutil.ToFloat64(btpOperatorStateGauge.WithLabelValues(cr.Namespace, cr.Name, string(types.StateError))))
+}
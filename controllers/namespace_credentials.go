@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const namespaceCredentialsLabelKey = "operator.kyma-project.io/credentials-namespace"
+
+// namespaceCredentialsRequiredKeys does not include cluster_id, the cluster ID is shared by all namespaces and taken from the required Secret
+var namespaceCredentialsRequiredKeys = []string{"clientid", "sm_url"}
+
+// namespaceCredentialsSecretName is the name of the Secret in the chart namespace from which SAP BTP Service Operator
+// reads the credentials for Service Instances and Service Bindings in the namespace
+func namespaceCredentialsSecretName(namespace string) string {
+	return fmt.Sprintf("%s-%s", namespace, btpServiceOperatorSecret)
+}
+
+// getNamespaceCredentialsResources verifies the Secrets referenced in spec.namespaceCredentials and returns
+// the per-namespace Secrets to apply together with the module resources. The Secrets get the managed-by label,
+// so the ones removed from the spec are pruned like any other module resource.
+func (r *BtpOperatorReconciler) getNamespaceCredentialsResources(ctx context.Context, cr *v1alpha1.BtpOperator) ([]*unstructured.Unstructured, *ErrorWithReason) {
+	logger := log.FromContext(ctx)
+
+	us := make([]*unstructured.Unstructured, 0)
+	namespaces := make(map[string]struct{})
+	for i := range cr.Spec.NamespaceCredentials {
+		credentials := cr.Spec.NamespaceCredentials[i]
+		ref := &credentials.SecretRef
+		if err := r.validateCredentialsSecretRef(ref); err != nil {
+			return nil, NewErrorWithReason(InvalidNamespaceCredentials, fmt.Sprintf("namespaceCredentials[%d]: %s", i, err))
+		}
+		if len(credentials.Namespaces) == 0 {
+			return nil, NewErrorWithReason(InvalidNamespaceCredentials, fmt.Sprintf("namespaceCredentials[%d]: no namespaces", i))
+		}
+
+		objKey := r.secretRefKey(ref)
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, objKey, secret); err != nil {
+			logger.Error(err, "while getting namespace credentials Secret", "name", objKey.Name, "namespace", objKey.Namespace)
+			if k8serrors.IsNotFound(err) {
+				return nil, NewErrorWithReason(InvalidNamespaceCredentials,
+					fmt.Sprintf("namespaceCredentials[%d]: Secret resource %s in %s namespace not found", i, objKey.Name, objKey.Namespace))
+			}
+			return nil, NewErrorWithReason(InvalidNamespaceCredentials, fmt.Sprintf("namespaceCredentials[%d]: unable to get Secret: %s", i, err))
+		}
+		secret = r.mapSecretKeys(secret, ref.KeyMapping)
+		if err := r.verifyCredentialsSecret(secret, namespaceCredentialsRequiredKeys); err != nil {
+			return nil, NewErrorWithReason(InvalidNamespaceCredentials,
+				fmt.Sprintf("namespaceCredentials[%d]: %s Secret in %s namespace: %s", i, objKey.Name, objKey.Namespace, err))
+		}
+
+		for _, namespace := range credentials.Namespaces {
+			if _, exists := namespaces[namespace]; exists {
+				return nil, NewErrorWithReason(InvalidNamespaceCredentials,
+					fmt.Sprintf("namespaceCredentials[%d]: credentials for %s namespace already defined", i, namespace))
+			}
+			namespaces[namespace] = struct{}{}
+			secrets, err := namespaceCredentialsSecrets(r.config().ChartNamespace, namespace, secret)
+			if err != nil {
+				return nil, NewErrorWithReason(InvalidNamespaceCredentials, fmt.Sprintf("namespaceCredentials[%d]: %s", i, err))
+			}
+			us = append(us, secrets...)
+		}
+	}
+
+	return us, nil
+}
+
+// namespaceCredentialsSecrets converts the credentials to the Secrets in the chartNamespace expected by SAP BTP Service Operator
+// for the namespace, the TLS Secret is added for X.509 credentials
+func namespaceCredentialsSecrets(chartNamespace, namespace string, credentials *corev1.Secret) ([]*unstructured.Unstructured, error) {
+	data := map[string][]byte{
+		"clientid":       credentials.Data["clientid"],
+		"sm_url":         credentials.Data["sm_url"],
+		"tokenurlsuffix": []byte(tokenPathSuffix),
+	}
+	if usesCertificateCredentials(credentials) {
+		data[tokenURLKey] = credentials.Data[certURLKey]
+	} else {
+		data[clientSecretKey] = credentials.Data[clientSecretKey]
+		data[tokenURLKey] = credentials.Data[tokenURLKey]
+	}
+	secrets := []*corev1.Secret{namespaceCredentialsSecret(chartNamespace, namespaceCredentialsSecretName(namespace), namespace, corev1.SecretTypeOpaque, data)}
+	if usesCertificateCredentials(credentials) {
+		secrets = append(secrets, namespaceCredentialsSecret(chartNamespace, namespaceCredentialsSecretName(namespace)+"-tls", namespace, corev1.SecretTypeTLS, map[string][]byte{
+			corev1.TLSCertKey:       credentials.Data[corev1.TLSCertKey],
+			corev1.TLSPrivateKeyKey: credentials.Data[corev1.TLSPrivateKeyKey],
+		}))
+	}
+
+	us := make([]*unstructured.Unstructured, 0, len(secrets))
+	for _, secret := range secrets {
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(secret)
+		if err != nil {
+			return nil, fmt.Errorf("while converting %s Secret: %w", secret.Name, err)
+		}
+		us = append(us, &unstructured.Unstructured{Object: object})
+	}
+	return us, nil
+}
+
+func namespaceCredentialsSecret(chartNamespace, name, namespace string, secretType corev1.SecretType, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: secretKind},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: chartNamespace,
+			Labels:    map[string]string{namespaceCredentialsLabelKey: namespace},
+		},
+		Type: secretType,
+		Data: data,
+	}
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func namespaceCredentialsTestClient(t *testing.T, objects ...client.Object) (client.Client, *runtime.Scheme) {
+	s := readinessTestScheme(t)
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build(), s
+}
+
+func TestGetNamespaceCredentialsResources(t *testing.T) {
+	// given
+	// subaccount credentials without cluster_id, the cluster ID comes from the required Secret
+	teamA := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "credentials"},
+		Data: map[string][]byte{
+			"client_id":    []byte("team-a"),
+			"clientsecret": []byte("secret"),
+			"sm_url":       []byte("https://sm.example.com"),
+			"tokenurl":     []byte("https://token.example.com"),
+		},
+	}
+	teamB := certificateCredentialsSecret(t, time.Now().Add(time.Hour))
+	teamB.ObjectMeta = metav1.ObjectMeta{Name: "team-b", Namespace: kymaNamespace}
+	c, s := namespaceCredentialsTestClient(t, teamA, teamB)
+	r := NewBtpOperatorReconciler(c, s)
+	cr := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{NamespaceCredentials: []v1alpha1.NamespaceCredentials{
+		{
+			Namespaces: []string{"team-a", "team-a-dev"},
+			SecretRef:  v1alpha1.CredentialsSecretRef{Name: "team-a", Namespace: "credentials", KeyMapping: map[string]string{"clientid": "client_id"}},
+		},
+		{
+			Namespaces: []string{"team-b"},
+			SecretRef:  v1alpha1.CredentialsSecretRef{Name: "team-b"},
+		},
+	}}}
+
+	// when
+	us, errWithReason := r.getNamespaceCredentialsResources(context.Background(), cr)
+
+	// then
+	require.Nil(t, errWithReason)
+	names := make([]string, 0, len(us))
+	for _, u := range us {
+		names = append(names, u.GetName())
+		assert.Equal(t, kymaNamespace, u.GetNamespace())
+		assert.Equal(t, secretKind, u.GetKind())
+	}
+	assert.Equal(t, []string{
+		"team-a-sap-btp-service-operator",
+		"team-a-dev-sap-btp-service-operator",
+		"team-b-sap-btp-service-operator",
+		"team-b-sap-btp-service-operator-tls",
+	}, names)
+
+	teamASecret := &corev1.Secret{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(us[0].Object, teamASecret))
+	assert.Equal(t, "team-a", teamASecret.Labels[namespaceCredentialsLabelKey])
+	assert.Equal(t, "team-a", string(teamASecret.Data["clientid"]))
+	assert.Equal(t, "secret", string(teamASecret.Data[clientSecretKey]))
+	assert.Equal(t, "https://token.example.com", string(teamASecret.Data[tokenURLKey]))
+	assert.NotContains(t, teamASecret.Data, "cluster_id")
+
+	teamBSecret := &corev1.Secret{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(us[2].Object, teamBSecret))
+	assert.Equal(t, "https://cert.example.com", string(teamBSecret.Data[tokenURLKey]))
+	assert.NotContains(t, teamBSecret.Data, clientSecretKey)
+	teamBTLSSecret := &corev1.Secret{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(us[3].Object, teamBTLSSecret))
+	assert.Equal(t, corev1.SecretTypeTLS, teamBTLSSecret.Type)
+	assert.Equal(t, teamB.Data[corev1.TLSCertKey], teamBTLSSecret.Data[corev1.TLSCertKey])
+}
+
+func TestGetNamespaceCredentialsResourcesErrors(t *testing.T) {
+	invalid := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: kymaNamespace},
+		Data:       map[string][]byte{"clientid": []byte("id")},
+	}
+	valid := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "valid", Namespace: kymaNamespace},
+		Data: map[string][]byte{
+			"clientid":     []byte("id"),
+			"clientsecret": []byte("secret"),
+			"sm_url":       []byte("https://sm.example.com"),
+			"tokenurl":     []byte("https://token.example.com"),
+			"cluster_id":   []byte("cluster"),
+		},
+	}
+	tests := []struct {
+		name            string
+		credentials     []v1alpha1.NamespaceCredentials
+		expectedMessage string
+	}{
+		{
+			name:            "missing Secret",
+			credentials:     []v1alpha1.NamespaceCredentials{{Namespaces: []string{"a"}, SecretRef: v1alpha1.CredentialsSecretRef{Name: "missing"}}},
+			expectedMessage: "namespaceCredentials[0]: Secret resource missing in kyma-system namespace not found",
+		},
+		{
+			name:            "invalid Secret",
+			credentials:     []v1alpha1.NamespaceCredentials{{Namespaces: []string{"a"}, SecretRef: v1alpha1.CredentialsSecretRef{Name: "invalid"}}},
+			expectedMessage: "namespaceCredentials[0]: invalid Secret in kyma-system namespace: key(s) sm_url, clientsecret, tokenurl not found",
+		},
+		{
+			name: "namespace with two credentials",
+			credentials: []v1alpha1.NamespaceCredentials{
+				{Namespaces: []string{"a"}, SecretRef: v1alpha1.CredentialsSecretRef{Name: "valid"}},
+				{Namespaces: []string{"a"}, SecretRef: v1alpha1.CredentialsSecretRef{Name: "valid"}},
+			},
+			expectedMessage: "namespaceCredentials[1]: credentials for a namespace already defined",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			c, s := namespaceCredentialsTestClient(t, invalid, valid)
+			r := NewBtpOperatorReconciler(c, s)
+			cr := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{NamespaceCredentials: tt.credentials}}
+
+			// when
+			_, errWithReason := r.getNamespaceCredentialsResources(context.Background(), cr)
+
+			// then
+			require.NotNil(t, errWithReason)
+			assert.Equal(t, InvalidNamespaceCredentials, errWithReason.reason)
+			assert.Equal(t, tt.expectedMessage, errWithReason.Error())
+		})
+	}
+}
+
+func TestRemovedNamespaceCredentialsArePruned(t *testing.T) {
+	// given
+	removed := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      namespaceCredentialsSecretName("removed"),
+		Namespace: kymaNamespace,
+		Labels:    map[string]string{managedByLabelKey: operatorName, namespaceCredentialsLabelKey: "removed"},
+	}}
+	s := readinessTestScheme(t)
+	c := fake.NewClientBuilder().WithScheme(s).WithRESTMapper(testRESTMapper()).WithObjects(removed).Build()
+	r := NewBtpOperatorReconciler(c, s)
+	kept, err := namespaceCredentialsSecrets(kymaNamespace, "kept", &corev1.Secret{})
+	require.NoError(t, err)
+	r.addLabels("v1", kept...)
+	for _, u := range kept {
+		require.NoError(t, c.Create(context.Background(), u.DeepCopy()))
+	}
+
+	// when
+	err = r.pruneResources(context.Background(), kept)
+
+	// then
+	require.NoError(t, err)
+	err = c.Get(context.Background(), client.ObjectKeyFromObject(removed), &corev1.Secret{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: namespaceCredentialsSecretName("kept"), Namespace: kymaNamespace}, &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": secretKind}}))
+}
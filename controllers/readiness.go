@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	crdKind                   = "CustomResourceDefinition"
+	mutatingWebhookKind       = "MutatingWebhookConfiguration"
+	validatingWebhookKind     = "ValidatingWebhookConfiguration"
+	endpointsKind             = "Endpoints"
+	defaultDeploymentReplicas = 1
+)
+
+// ResourceNotReadyError describes a module resource which did not become ready
+type ResourceNotReadyError struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+	Reason    string
+}
+
+func (e *ResourceNotReadyError) Error() string {
+	if e.Namespace == "" {
+		return fmt.Sprintf("%s %s: %s", e.Kind, e.Name, e.Reason)
+	}
+	return fmt.Sprintf("%s %s/%s: %s", e.Kind, e.Namespace, e.Name, e.Reason)
+}
+
+// ResourcesNotReadyError is returned when module resources do not become ready before the timeout
+type ResourcesNotReadyError struct {
+	NotReady []*ResourceNotReadyError
+}
+
+func (e *ResourcesNotReadyError) Error() string {
+	msgs := make([]string, 0, len(e.NotReady))
+	for _, err := range e.NotReady {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("resources readiness timeout reached, %d resource(s) not ready: %s", len(e.NotReady), strings.Join(msgs, "; "))
+}
+
+func (r *BtpOperatorReconciler) waitForResourcesReadiness(ctx context.Context, cr *v1alpha1.BtpOperator, us []*unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+	cfg := r.config()
+	deadline := time.Now().Add(cfg.ReadyTimeout)
+
+	for {
+		notReady := r.checkResourcesReadiness(ctx, us)
+		r.setResourcesReadiness(cr, us, notReady)
+		if len(notReady) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			for _, err := range notReady {
+				logger.Info("module resource not ready", "kind", err.Kind, "namespace", err.Namespace, "name", err.Name, "reason", err.Reason)
+			}
+			return &ResourcesNotReadyError{NotReady: notReady}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cfg.ReadyCheckInterval):
+		}
+	}
+}
+
+func (r *BtpOperatorReconciler) checkResourcesReadiness(ctx context.Context, us []*unstructured.Unstructured) []*ResourceNotReadyError {
+	notReady := make([]*ResourceNotReadyError, 0)
+	for _, u := range us {
+		if reason := r.checkResourceReadiness(ctx, u); reason != "" {
+			notReady = append(notReady, &ResourceNotReadyError{
+				Group:     u.GroupVersionKind().Group,
+				Kind:      u.GetKind(),
+				Namespace: u.GetNamespace(),
+				Name:      u.GetName(),
+				Reason:    reason,
+			})
+		}
+	}
+	return notReady
+}
+
+// checkResourceReadiness returns the reason why the resource is not ready or an empty string if it is ready
+func (r *BtpOperatorReconciler) checkResourceReadiness(ctx context.Context, u *unstructured.Unstructured) string {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, r.config().ReadyCheckInterval/2)
+	defer cancel()
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(u.GroupVersionKind())
+	if err := r.Get(ctxWithTimeout, client.ObjectKey{Name: u.GetName(), Namespace: u.GetNamespace()}, got); err != nil {
+		return fmt.Sprintf("unable to get the resource: %s", err)
+	}
+
+	var reason string
+	var err error
+	switch u.GetKind() {
+	case deploymentKind:
+		reason, err = r.deploymentNotReadyReason(got)
+	case crdKind:
+		reason, err = r.crdNotReadyReason(got)
+	case mutatingWebhookKind:
+		reason, err = r.mutatingWebhookNotReadyReason(ctxWithTimeout, got)
+	case validatingWebhookKind:
+		reason, err = r.validatingWebhookNotReadyReason(ctxWithTimeout, got)
+	}
+	if err != nil {
+		return fmt.Sprintf("unable to check readiness: %s", err)
+	}
+
+	return reason
+}
+
+func (r *BtpOperatorReconciler) deploymentNotReadyReason(u *unstructured.Unstructured) (string, error) {
+	deployment := &appsv1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, deployment); err != nil {
+		return "", err
+	}
+
+	replicas := int32(defaultDeploymentReplicas)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	switch {
+	case status.ObservedGeneration < deployment.Generation:
+		return "waiting for the Deployment spec update to be observed", nil
+	case status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("%d of %d replicas updated", status.UpdatedReplicas, replicas), nil
+	case status.Replicas > status.UpdatedReplicas:
+		return fmt.Sprintf("%d old replica(s) pending termination", status.Replicas-status.UpdatedReplicas), nil
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return fmt.Sprintf("%d of %d updated replicas available", status.AvailableReplicas, status.UpdatedReplicas), nil
+	}
+	for _, c := range status.Conditions {
+		if c.Type == appsv1.DeploymentAvailable && c.Status != corev1.ConditionTrue {
+			return fmt.Sprintf("Deployment not available: %s", c.Message), nil
+		}
+	}
+
+	return "", nil
+}
+
+func (r *BtpOperatorReconciler) crdNotReadyReason(u *unstructured.Unstructured) (string, error) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, crd); err != nil {
+		return "", err
+	}
+
+	for _, conditionType := range []apiextensionsv1.CustomResourceDefinitionConditionType{apiextensionsv1.Established, apiextensionsv1.NamesAccepted} {
+		condition := findCrdCondition(crd, conditionType)
+		if condition == nil {
+			return fmt.Sprintf("%s condition not reported", conditionType), nil
+		}
+		if condition.Status != apiextensionsv1.ConditionTrue {
+			return fmt.Sprintf("%s condition is %s: %s", conditionType, condition.Status, condition.Message), nil
+		}
+	}
+
+	return "", nil
+}
+
+func findCrdCondition(crd *apiextensionsv1.CustomResourceDefinition, conditionType apiextensionsv1.CustomResourceDefinitionConditionType) *apiextensionsv1.CustomResourceDefinitionCondition {
+	for i := range crd.Status.Conditions {
+		if crd.Status.Conditions[i].Type == conditionType {
+			return &crd.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func (r *BtpOperatorReconciler) mutatingWebhookNotReadyReason(ctx context.Context, u *unstructured.Unstructured) (string, error) {
+	webhookConfig := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, webhookConfig); err != nil {
+		return "", err
+	}
+	for _, w := range webhookConfig.Webhooks {
+		if reason, err := r.webhookClientConfigNotReadyReason(ctx, w.Name, w.ClientConfig); reason != "" || err != nil {
+			return reason, err
+		}
+	}
+	return "", nil
+}
+
+func (r *BtpOperatorReconciler) validatingWebhookNotReadyReason(ctx context.Context, u *unstructured.Unstructured) (string, error) {
+	webhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, webhookConfig); err != nil {
+		return "", err
+	}
+	for _, w := range webhookConfig.Webhooks {
+		if reason, err := r.webhookClientConfigNotReadyReason(ctx, w.Name, w.ClientConfig); reason != "" || err != nil {
+			return reason, err
+		}
+	}
+	return "", nil
+}
+
+func (r *BtpOperatorReconciler) webhookClientConfigNotReadyReason(ctx context.Context, webhookName string, clientConfig admissionregistrationv1.WebhookClientConfig) (string, error) {
+	if len(clientConfig.CABundle) == 0 {
+		return fmt.Sprintf("webhook %s has empty caBundle", webhookName), nil
+	}
+	if clientConfig.Service == nil {
+		return "", nil
+	}
+
+	service := clientConfig.Service
+	endpointsUnstructured := &unstructured.Unstructured{}
+	endpointsUnstructured.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: endpointsKind})
+	if err := r.Get(ctx, client.ObjectKey{Namespace: service.Namespace, Name: service.Name}, endpointsUnstructured); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return fmt.Sprintf("webhook %s service %s/%s has no endpoints", webhookName, service.Namespace, service.Name), nil
+		}
+		return "", err
+	}
+	endpoints := &corev1.Endpoints{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(endpointsUnstructured.Object, endpoints); err != nil {
+		return "", err
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return "", nil
+		}
+	}
+
+	return fmt.Sprintf("webhook %s service %s/%s has no ready endpoints", webhookName, service.Namespace, service.Name), nil
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	testWebhookServiceName = "sap-btp-operator-webhook-service"
+)
+
+func TestCheckResourceReadiness(t *testing.T) {
+	// given
+	replicas := int32(2)
+	availableDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: kymaNamespace, Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  2,
+			Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	caBundle := []byte("ca")
+
+	tests := []struct {
+		name           string
+		objects        []client.Object
+		resource       client.Object
+		expectedReason string
+	}{
+		{
+			name:           "missing resource",
+			resource:       &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: kymaNamespace}},
+			expectedReason: "unable to get the resource",
+		},
+		{
+			name:     "existing secret",
+			objects:  []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: kymaNamespace}}},
+			resource: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: kymaNamespace}},
+		},
+		{
+			name:     "available deployment",
+			objects:  []client.Object{availableDeployment},
+			resource: availableDeployment,
+		},
+		{
+			name: "deployment spec update not observed",
+			objects: []client.Object{withDeploymentStatus(availableDeployment, func(s *appsv1.DeploymentStatus) {
+				s.ObservedGeneration = 1
+			})},
+			resource:       availableDeployment,
+			expectedReason: "waiting for the Deployment spec update to be observed",
+		},
+		{
+			name: "deployment rollout in progress",
+			objects: []client.Object{withDeploymentStatus(availableDeployment, func(s *appsv1.DeploymentStatus) {
+				s.UpdatedReplicas = 1
+			})},
+			resource:       availableDeployment,
+			expectedReason: "1 of 2 replicas updated",
+		},
+		{
+			name: "deployment with old replicas",
+			objects: []client.Object{withDeploymentStatus(availableDeployment, func(s *appsv1.DeploymentStatus) {
+				s.Replicas = 3
+			})},
+			resource:       availableDeployment,
+			expectedReason: "1 old replica(s) pending termination",
+		},
+		{
+			name: "deployment with unavailable replicas",
+			objects: []client.Object{withDeploymentStatus(availableDeployment, func(s *appsv1.DeploymentStatus) {
+				s.AvailableReplicas = 0
+			})},
+			resource:       availableDeployment,
+			expectedReason: "0 of 2 updated replicas available",
+		},
+		{
+			name: "deployment with false Available condition",
+			objects: []client.Object{withDeploymentStatus(availableDeployment, func(s *appsv1.DeploymentStatus) {
+				s.Conditions[0].Status = corev1.ConditionFalse
+				s.Conditions[0].Message = "Deployment does not have minimum availability."
+			})},
+			resource:       availableDeployment,
+			expectedReason: "Deployment not available: Deployment does not have minimum availability.",
+		},
+		{
+			name:     "established CRD",
+			objects:  []client.Object{testCrd(apiextensionsv1.ConditionTrue, apiextensionsv1.ConditionTrue)},
+			resource: testCrd(apiextensionsv1.ConditionTrue, apiextensionsv1.ConditionTrue),
+		},
+		{
+			name:           "CRD not established",
+			objects:        []client.Object{testCrd(apiextensionsv1.ConditionFalse, apiextensionsv1.ConditionTrue)},
+			resource:       testCrd(apiextensionsv1.ConditionFalse, apiextensionsv1.ConditionTrue),
+			expectedReason: "Established condition is False",
+		},
+		{
+			name:           "CRD names not accepted",
+			objects:        []client.Object{testCrd(apiextensionsv1.ConditionTrue, apiextensionsv1.ConditionFalse)},
+			resource:       testCrd(apiextensionsv1.ConditionTrue, apiextensionsv1.ConditionFalse),
+			expectedReason: "NamesAccepted condition is False",
+		},
+		{
+			name:           "webhook without caBundle",
+			objects:        []client.Object{testValidatingWebhook(nil), testEndpoints(true)},
+			resource:       testValidatingWebhook(nil),
+			expectedReason: "webhook vservicebinding.kb.io has empty caBundle",
+		},
+		{
+			name:           "webhook without endpoints",
+			objects:        []client.Object{testMutatingWebhook(caBundle)},
+			resource:       testMutatingWebhook(caBundle),
+			expectedReason: "webhook mservicebinding.kb.io service kyma-system/sap-btp-operator-webhook-service has no endpoints",
+		},
+		{
+			name:           "webhook without ready endpoints",
+			objects:        []client.Object{testMutatingWebhook(caBundle), testEndpoints(false)},
+			resource:       testMutatingWebhook(caBundle),
+			expectedReason: "webhook mservicebinding.kb.io service kyma-system/sap-btp-operator-webhook-service has no ready endpoints",
+		},
+		{
+			name:     "webhook with ready endpoints",
+			objects:  []client.Object{testValidatingWebhook(caBundle), testEndpoints(true)},
+			resource: testValidatingWebhook(caBundle),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := readinessTestScheme(t)
+			r := NewBtpOperatorReconciler(fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...).Build(), s)
+
+			// when
+			reason := r.checkResourceReadiness(context.Background(), toUnstructured(t, s, tt.resource))
+
+			// then
+			if tt.expectedReason == "" {
+				assert.Empty(t, reason)
+			} else {
+				assert.Contains(t, reason, tt.expectedReason)
+			}
+		})
+	}
+}
+
+func TestWaitForResourcesReadinessReportsNotReadyResources(t *testing.T) {
+	// given
+	s := readinessTestScheme(t)
+	crd := testCrd(apiextensionsv1.ConditionFalse, apiextensionsv1.ConditionTrue)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: kymaNamespace}}
+	r := NewBtpOperatorReconciler(fake.NewClientBuilder().WithScheme(s).WithObjects(crd, secret).Build(), s)
+	cfg := DefaultConfig()
+	cfg.ReadyTimeout, cfg.ReadyCheckInterval = time.Millisecond*50, time.Millisecond*10
+	r.SetConfig(cfg)
+	us := []*unstructured.Unstructured{toUnstructured(t, s, secret), toUnstructured(t, s, crd)}
+	cr := &v1alpha1.BtpOperator{}
+	r.setResourcesStatus(cr, us)
+
+	// when
+	err := r.waitForResourcesReadiness(context.Background(), cr, us)
+
+	// then
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 resource(s) not ready")
+	assert.Contains(t, err.Error(), "CustomResourceDefinition serviceinstances.services.cloud.sap.com: Established condition is False")
+	assert.NotContains(t, err.Error(), "Secret")
+	require.Len(t, cr.Status.Resources, 2)
+	assert.True(t, cr.Status.Resources[0].Ready)
+	assert.Empty(t, cr.Status.Resources[0].LastError)
+	assert.False(t, cr.Status.Resources[1].Ready)
+	assert.Contains(t, cr.Status.Resources[1].LastError, "Established condition is False")
+}
+
+func readinessTestScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, apiextensionsv1.AddToScheme(s))
+	return s
+}
+
+func toUnstructured(t *testing.T, s *runtime.Scheme, obj client.Object) *unstructured.Unstructured {
+	gvks, _, err := s.ObjectKinds(obj)
+	require.NoError(t, err)
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvks[0])
+	return u
+}
+
+func withDeploymentStatus(deployment *appsv1.Deployment, mutate func(*appsv1.DeploymentStatus)) *appsv1.Deployment {
+	d := deployment.DeepCopy()
+	mutate(&d.Status)
+	return d
+}
+
+func testCrd(established, namesAccepted apiextensionsv1.ConditionStatus) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "serviceinstances.services.cloud.sap.com"},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.NamesAccepted, Status: namesAccepted},
+				{Type: apiextensionsv1.Established, Status: established},
+			},
+		},
+	}
+}
+
+func testWebhookClientConfig(caBundle []byte) admissionregistrationv1.WebhookClientConfig {
+	return admissionregistrationv1.WebhookClientConfig{
+		Service:  &admissionregistrationv1.ServiceReference{Name: testWebhookServiceName, Namespace: kymaNamespace},
+		CABundle: caBundle,
+	}
+}
+
+func testMutatingWebhook(caBundle []byte) *admissionregistrationv1.MutatingWebhookConfiguration {
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "sap-btp-operator-mutating-webhook-configuration"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{Name: "mservicebinding.kb.io", ClientConfig: testWebhookClientConfig(caBundle)},
+		},
+	}
+}
+
+func testValidatingWebhook(caBundle []byte) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "sap-btp-operator-validating-webhook-configuration"},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{Name: "vservicebinding.kb.io", ClientConfig: testWebhookClientConfig(caBundle)},
+		},
+	}
+}
+
+func testEndpoints(ready bool) *corev1.Endpoints {
+	subset := corev1.EndpointSubset{Ports: []corev1.EndpointPort{{Port: 9443}}}
+	address := corev1.EndpointAddress{IP: "10.0.0.1"}
+	if ready {
+		subset.Addresses = []corev1.EndpointAddress{address}
+	} else {
+		subset.NotReadyAddresses = []corev1.EndpointAddress{address}
+	}
+	return &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: testWebhookServiceName, Namespace: kymaNamespace},
+		Subsets:    []corev1.EndpointSubset{subset},
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// setResourcesStatus replaces the resources inventory in the CR status with not ready entries for the given resources
+func (r *BtpOperatorReconciler) setResourcesStatus(cr *v1alpha1.BtpOperator, us []*unstructured.Unstructured) {
+	resources := make([]v1alpha1.ResourceStatus, 0, len(us))
+	for _, u := range us {
+		resources = append(resources, v1alpha1.ResourceStatus{
+			Group:        u.GroupVersionKind().Group,
+			Kind:         u.GetKind(),
+			Namespace:    u.GetNamespace(),
+			Name:         u.GetName(),
+			ChartVersion: u.GetLabels()[chartVersionKey],
+		})
+	}
+	cr.Status.Resources = resources
+}
+
+// setResourceError marks the resource in the CR status inventory as not ready with the given error message
+func (r *BtpOperatorReconciler) setResourceError(cr *v1alpha1.BtpOperator, u *unstructured.Unstructured, message string) {
+	if resource := findResourceStatus(cr, u); resource != nil {
+		resource.Ready = false
+		resource.LastError = message
+	}
+}
+
+// setResourcesReadiness updates readiness of all resources in the CR status inventory based on readiness check results
+func (r *BtpOperatorReconciler) setResourcesReadiness(cr *v1alpha1.BtpOperator, us []*unstructured.Unstructured, notReady []*ResourceNotReadyError) {
+	for _, u := range us {
+		if resource := findResourceStatus(cr, u); resource != nil {
+			resource.Ready = true
+			resource.LastError = ""
+		}
+	}
+	for _, nr := range notReady {
+		for i := range cr.Status.Resources {
+			resource := &cr.Status.Resources[i]
+			if resource.Group == nr.Group && resource.Kind == nr.Kind && resource.Namespace == nr.Namespace && resource.Name == nr.Name {
+				resource.Ready = false
+				resource.LastError = nr.Reason
+			}
+		}
+	}
+}
+
+func findResourceStatus(cr *v1alpha1.BtpOperator, u *unstructured.Unstructured) *v1alpha1.ResourceStatus {
+	group := u.GroupVersionKind().Group
+	for i := range cr.Status.Resources {
+		resource := &cr.Status.Resources[i]
+		if resource.Group == group && resource.Kind == u.GetKind() && resource.Namespace == u.GetNamespace() && resource.Name == u.GetName() {
+			return resource
+		}
+	}
+	return nil
+}
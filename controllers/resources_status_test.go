@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSetResourcesStatus(t *testing.T) {
+	// given
+	r := &BtpOperatorReconciler{}
+	cr := &v1alpha1.BtpOperator{}
+	cr.Status.Resources = []v1alpha1.ResourceStatus{{Kind: "Secret", Name: "outdated", Ready: true}}
+	deployment := testUnstructured(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: deploymentKind}, kymaNamespace, deploymentName)
+	r.addLabels("v0.2.3", deployment)
+	crd := testUnstructured(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: crdKind}, "", "servicebindings.services.cloud.sap.com")
+
+	// when
+	r.setResourcesStatus(cr, []*unstructured.Unstructured{deployment, crd})
+
+	// then
+	require.Len(t, cr.Status.Resources, 2)
+	assert.Equal(t, v1alpha1.ResourceStatus{
+		Group:        "apps",
+		Kind:         deploymentKind,
+		Namespace:    kymaNamespace,
+		Name:         deploymentName,
+		ChartVersion: "v0.2.3",
+	}, cr.Status.Resources[0])
+	assert.Equal(t, v1alpha1.ResourceStatus{
+		Group: "apiextensions.k8s.io",
+		Kind:  crdKind,
+		Name:  "servicebindings.services.cloud.sap.com",
+	}, cr.Status.Resources[1])
+}
+
+func TestSetResourceError(t *testing.T) {
+	// given
+	r := &BtpOperatorReconciler{}
+	cr := &v1alpha1.BtpOperator{}
+	secret := testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, kymaNamespace, "secret")
+	configMap := testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, kymaNamespace, "secret")
+	r.setResourcesStatus(cr, []*unstructured.Unstructured{secret, configMap})
+	cr.Status.Resources[0].Ready = true
+
+	// when
+	r.setResourceError(cr, secret, "apply failed")
+
+	// then
+	assert.False(t, cr.Status.Resources[0].Ready)
+	assert.Equal(t, "apply failed", cr.Status.Resources[0].LastError)
+	assert.Empty(t, cr.Status.Resources[1].LastError)
+}
+
+func testUnstructured(gvk schema.GroupVersionKind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
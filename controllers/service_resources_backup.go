@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	serviceResourcesBackupSecret = "btp-manager-service-resources-backup"
+	serviceInstancesBackupKey    = "serviceinstances.json.gz"
+	serviceBindingsBackupKey     = "servicebindings.json.gz"
+	bindingSecretsBackupKey      = "bindingsecrets.json.gz"
+	backupTimeAnnotation         = "operator.kyma-project.io/backup-time"
+	backupOwnerUIDAnnotation     = "operator.kyma-project.io/btpoperator-uid"
+
+	ServiceResourcesBackedUp      Reason = "ServiceResourcesBackedUp"
+	ServiceResourcesRestored      Reason = "ServiceResourcesRestored"
+	ServiceResourcesRestoreFailed Reason = "ServiceResourcesRestoreFailed"
+)
+
+// bindingSecretBackup holds metadata of a Secret generated for a Service Binding, the credentials are not backed up
+type bindingSecretBackup struct {
+	Namespace   string            `json:"namespace"`
+	Name        string            `json:"name"`
+	Binding     string            `json:"binding"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Keys        []string          `json:"keys"`
+}
+
+// backupServiceResources exports Service Instances, Service Bindings and metadata of the binding Secrets
+// to the backup Secret in the chart namespace, so that they can be restored after the module is reinstalled.
+// The Secret is not labeled as a module resource, so it is kept when the module resources are deleted.
+// Objects backed up by a previous, interrupted soft delete of the same BtpOperator CR are kept in the backup.
+// The data is compressed, a backup which still does not fit into the Secret fails with the ServiceResourcesBackupFailed reason.
+func (r *BtpOperatorReconciler) backupServiceResources(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+	logger := log.FromContext(ctx)
+	logger.Info("Backing up Service Instances and Service Bindings")
+
+	instances, err := r.listServiceResources(ctx, r.GvkToList(instanceGvk))
+	if err != nil {
+		return fmt.Errorf("while listing Service Instances: %w", err)
+	}
+	bindings, err := r.listServiceResources(ctx, r.GvkToList(bindingGvk))
+	if err != nil {
+		return fmt.Errorf("while listing Service Bindings: %w", err)
+	}
+
+	bindingSecrets := make([]bindingSecretBackup, 0, len(bindings))
+	for i := range bindings {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: bindings[i].GetNamespace(), Name: bindingSecretName(&bindings[i])}
+		if err := r.Get(ctx, key, secret); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("while getting Service Binding Secret %s: %w", key, err)
+		}
+		bindingSecrets = append(bindingSecrets, toBindingSecretBackup(secret, bindings[i].GetName()))
+	}
+
+	backup := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceResourcesBackupSecret,
+			Namespace: r.config().ChartNamespace,
+		},
+	}
+	if _, err := ctrlutil.CreateOrUpdate(ctx, r.Client, backup, func() error {
+		previous := map[string][]byte{}
+		if backup.Annotations[backupOwnerUIDAnnotation] == string(cr.GetUID()) {
+			previous = backup.Data
+		}
+		instancesData, err := mergeBackupObjects(previous[serviceInstancesBackupKey], toBackupObjects(instances))
+		if err != nil {
+			return err
+		}
+		bindingsData, err := mergeBackupObjects(previous[serviceBindingsBackupKey], toBackupObjects(bindings))
+		if err != nil {
+			return err
+		}
+		bindingSecretsData, err := mergeBindingSecretsBackup(previous[bindingSecretsBackupKey], bindingSecrets)
+		if err != nil {
+			return err
+		}
+
+		if backup.Annotations == nil {
+			backup.Annotations = make(map[string]string)
+		}
+		backup.Annotations[backupTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		backup.Annotations[backupOwnerUIDAnnotation] = string(cr.GetUID())
+		backup.Data = map[string][]byte{
+			serviceInstancesBackupKey: instancesData,
+			serviceBindingsBackupKey:  bindingsData,
+			bindingSecretsBackupKey:   bindingSecretsData,
+		}
+		if size := len(instancesData) + len(bindingsData) + len(bindingSecretsData); size > corev1.MaxSecretSize {
+			return NewErrorWithReason(ServiceResourcesBackupFailed, fmt.Sprintf(
+				"Backup of %d Service Instance(s) and %d Service Binding(s) takes %d bytes compressed, more than %d bytes allowed in %s/%s Secret",
+				len(instances), len(bindings), size, corev1.MaxSecretSize, backup.Namespace, serviceResourcesBackupSecret))
+		}
+		return nil
+	}); err != nil {
+		var reasonErr *ErrorWithReason
+		if errors.As(err, &reasonErr) {
+			return err
+		}
+		return NewErrorWithReason(ServiceResourcesBackupFailed, fmt.Sprintf("Unable to store Service Instances and Service Bindings backup in %s/%s Secret: %s",
+			backup.Namespace, serviceResourcesBackupSecret, err))
+	}
+
+	r.recordEvent(cr, corev1.EventTypeNormal, ServiceResourcesBackedUp, fmt.Sprintf(
+		"%d Service Instance(s) and %d Service Binding(s) backed up in %s/%s Secret",
+		len(instances), len(bindings), backup.Namespace, serviceResourcesBackupSecret))
+
+	return nil
+}
+
+// handleServiceResourcesRestore recreates Service Instances and Service Bindings from the backup Secret,
+// so that SAP BTP Service Operator adopts the existing instances, and removes the restore annotation from the CR
+func (r *BtpOperatorReconciler) handleServiceResourcesRestore(ctx context.Context, cr *v1alpha1.BtpOperator) error {
+	logger := log.FromContext(ctx)
+	logger.Info("Restoring Service Instances and Service Bindings from the backup")
+	namespace := r.config().ChartNamespace
+
+	restored, existing, err := r.restoreServiceResources(ctx)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		r.recordEvent(cr, corev1.EventTypeWarning, ServiceResourcesRestoreFailed,
+			fmt.Sprintf("%s/%s backup Secret not found", namespace, serviceResourcesBackupSecret))
+	} else {
+		r.recordEvent(cr, corev1.EventTypeNormal, ServiceResourcesRestored, fmt.Sprintf(
+			"%d Service Instance(s) and Service Binding(s) restored from %s/%s Secret, %d already existed",
+			restored, namespace, serviceResourcesBackupSecret, existing))
+	}
+
+	annotations := cr.GetAnnotations()
+	delete(annotations, v1alpha1.RestoreServiceResourcesAnnotation)
+	cr.SetAnnotations(annotations)
+
+	return r.Update(ctx, cr)
+}
+
+// restoreServiceResources creates the backed up Service Instances first and then the Service Bindings.
+// It returns the number of created objects and the number of objects which already exist.
+func (r *BtpOperatorReconciler) restoreServiceResources(ctx context.Context) (int, int, error) {
+	backup := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.config().ChartNamespace, Name: serviceResourcesBackupSecret}, backup); err != nil {
+		return 0, 0, err
+	}
+
+	restored, existing := 0, 0
+	for _, key := range []string{serviceInstancesBackupKey, serviceBindingsBackupKey} {
+		data, err := decompress(backup.Data[key])
+		if err != nil {
+			return restored, existing, fmt.Errorf("while decompressing %s from %s backup Secret: %w", key, serviceResourcesBackupSecret, err)
+		}
+		objects := make([]*unstructured.Unstructured, 0)
+		if err := json.Unmarshal(data, &objects); err != nil {
+			return restored, existing, fmt.Errorf("while parsing %s from %s backup Secret: %w", key, serviceResourcesBackupSecret, err)
+		}
+		for _, u := range objects {
+			unstructured.RemoveNestedField(u.Object, "status")
+			if err := r.Create(ctx, u); err != nil {
+				if k8serrors.IsAlreadyExists(err) {
+					existing++
+					continue
+				}
+				return restored, existing, fmt.Errorf("while restoring %s %s/%s: %w", u.GetKind(), u.GetNamespace(), u.GetName(), err)
+			}
+			restored++
+		}
+	}
+
+	return restored, existing, nil
+}
+
+// toBackupObjects strips server-side metadata from the objects, keeping spec and status with the instance IDs
+func toBackupObjects(items []unstructured.Unstructured) []*unstructured.Unstructured {
+	objects := make([]*unstructured.Unstructured, 0, len(items))
+	for _, item := range items {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetGroupVersionKind(item.GroupVersionKind())
+		u.SetNamespace(item.GetNamespace())
+		u.SetName(item.GetName())
+		u.SetLabels(item.GetLabels())
+		u.SetAnnotations(item.GetAnnotations())
+		for _, field := range []string{"spec", "status"} {
+			if value, ok := item.Object[field]; ok {
+				u.Object[field] = value
+			}
+		}
+		objects = append(objects, u)
+	}
+	return objects
+}
+
+// mergeBackupObjects adds previously backed up objects which are not present in the current objects
+func mergeBackupObjects(previousData []byte, current []*unstructured.Unstructured) ([]byte, error) {
+	previous := make([]*unstructured.Unstructured, 0)
+	if err := unmarshalPreviousBackup(previousData, &previous); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]struct{}, len(current))
+	for _, u := range current {
+		keys[resourceKey(u)] = struct{}{}
+	}
+	merged := current
+	for _, u := range previous {
+		if _, ok := keys[resourceKey(u)]; !ok {
+			merged = append(merged, u)
+		}
+	}
+	return marshalBackup(merged)
+}
+
+func mergeBindingSecretsBackup(previousData []byte, current []bindingSecretBackup) ([]byte, error) {
+	previous := make([]bindingSecretBackup, 0)
+	if err := unmarshalPreviousBackup(previousData, &previous); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]struct{}, len(current))
+	for _, secret := range current {
+		keys[secret.Namespace+"/"+secret.Name] = struct{}{}
+	}
+	merged := current
+	for _, secret := range previous {
+		if _, ok := keys[secret.Namespace+"/"+secret.Name]; !ok {
+			merged = append(merged, secret)
+		}
+	}
+	return marshalBackup(merged)
+}
+
+func unmarshalPreviousBackup(previousData []byte, v interface{}) error {
+	if len(previousData) == 0 {
+		return nil
+	}
+	data, err := decompress(previousData)
+	if err != nil {
+		return fmt.Errorf("while decompressing previous backup: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("while parsing previous backup: %w", err)
+	}
+	return nil
+}
+
+func marshalBackup(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return compress(data)
+}
+
+// compress gzips the data stored in Secrets, so that the backed up objects and the rendered module resources fit into them
+func compress(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+func toBindingSecretBackup(secret *corev1.Secret, binding string) bindingSecretBackup {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return bindingSecretBackup{
+		Namespace:   secret.Namespace,
+		Name:        secret.Name,
+		Binding:     binding,
+		Labels:      secret.Labels,
+		Annotations: secret.Annotations,
+		Keys:        keys,
+	}
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/kyma-project/module-manager/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBackupAndRestoreServiceResources(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+
+	instance := testUnstructured(instanceGvk, "test", "si")
+	require.NoError(t, unstructured.SetNestedField(instance.Object, "offering", "spec", "serviceOfferingName"))
+	require.NoError(t, unstructured.SetNestedField(instance.Object, "instance-id", "status", "instanceID"))
+	binding := testUnstructured(bindingGvk, "test", "sb")
+	require.NoError(t, unstructured.SetNestedField(binding.Object, "si", "spec", "serviceInstanceName"))
+	bindingSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sb", Namespace: "test", Labels: map[string]string{"app": "test"}},
+		Data:       map[string][]byte{"password": []byte("secret"), "clientid": []byte("id")},
+	}
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default", UID: "backup-uid"}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(cr, instance, binding, bindingSecret).Build()
+	r := NewBtpOperatorReconciler(c, s)
+
+	// when
+	err := r.backupServiceResources(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+	backup := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: serviceResourcesBackupSecret, Namespace: kymaNamespace}, backup))
+	assert.Equal(t, "backup-uid", backup.Annotations[backupOwnerUIDAnnotation])
+	assert.NotContains(t, backup.Labels, managedByLabelKey)
+	assert.NotContains(t, string(backup.Data[serviceInstancesBackupKey]), "instance-id", "backup should be compressed")
+	instancesData, err := decompress(backup.Data[serviceInstancesBackupKey])
+	require.NoError(t, err)
+	assert.Contains(t, string(instancesData), "instance-id")
+	bindingSecretsData, err := decompress(backup.Data[bindingSecretsBackupKey])
+	require.NoError(t, err)
+	bindingSecrets := make([]bindingSecretBackup, 0)
+	require.NoError(t, json.Unmarshal(bindingSecretsData, &bindingSecrets))
+	assert.Equal(t, []bindingSecretBackup{{
+		Namespace: "test",
+		Name:      "sb",
+		Binding:   "sb",
+		Labels:    map[string]string{"app": "test"},
+		Keys:      []string{"clientid", "password"},
+	}}, bindingSecrets)
+	assert.NotContains(t, string(bindingSecretsData), "secret\"")
+
+	// given
+	require.NoError(t, c.Delete(context.Background(), binding))
+	require.NoError(t, c.Delete(context.Background(), instance))
+
+	// when
+	restored, existing, err := r.restoreServiceResources(context.Background())
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+	assert.Equal(t, 0, existing)
+	restoredInstance := testUnstructured(instanceGvk, "", "")
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "si", Namespace: "test"}, restoredInstance))
+	offering, _, _ := unstructured.NestedString(restoredInstance.Object, "spec", "serviceOfferingName")
+	assert.Equal(t, "offering", offering)
+	assert.NotContains(t, restoredInstance.Object, "status")
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "sb", Namespace: "test"}, testUnstructured(bindingGvk, "", "")))
+
+	// when
+	restored, existing, err = r.restoreServiceResources(context.Background())
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored)
+	assert.Equal(t, 2, existing)
+}
+
+func TestBackupServiceResourcesKeepsPreviousBackupOfTheSameCr(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default", UID: "backup-uid"}}
+	instance := testUnstructured(instanceGvk, "test", "si")
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(cr, instance, testUnstructured(instanceGvk, "test", "other")).Build()
+	r := NewBtpOperatorReconciler(c, s)
+	require.NoError(t, r.backupServiceResources(context.Background(), cr))
+	require.NoError(t, c.Delete(context.Background(), instance))
+
+	// when
+	err := r.backupServiceResources(context.Background(), cr)
+
+	// then
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"si", "other"}, backedUpInstances(t, c))
+
+	// when
+	err = r.backupServiceResources(context.Background(), &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{UID: "new-uid"}})
+
+	// then
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"other"}, backedUpInstances(t, c))
+}
+
+func TestBackupServiceResourcesExceedingSecretSize(t *testing.T) {
+	// given
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	cr := &v1alpha1.BtpOperator{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default", UID: "backup-uid"}}
+	defer deleteBtpOperatorState(cr.Namespace, cr.Name)
+	objects := []client.Object{cr}
+	for _, name := range []string{"si-1", "si-2"} {
+		parameters := make([]byte, corev1.MaxSecretSize/2)
+		_, err := rand.Read(parameters)
+		require.NoError(t, err)
+		instance := testUnstructured(instanceGvk, "test", name)
+		require.NoError(t, unstructured.SetNestedField(instance.Object, base64.StdEncoding.EncodeToString(parameters), "spec", "parameters"))
+		objects = append(objects, instance)
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+	r := NewBtpOperatorReconciler(c, s)
+
+	// when
+	err := r.backupServiceResources(context.Background(), cr)
+
+	// then
+	require.Error(t, err)
+	assert.Equal(t, ServiceResourcesBackupFailed, reasonOf(err, ResourceRemovalFailed))
+	assert.Contains(t, err.Error(), "Backup of 2 Service Instance(s) and 0 Service Binding(s) takes")
+	err = c.Get(context.Background(), client.ObjectKey{Name: serviceResourcesBackupSecret, Namespace: kymaNamespace}, &corev1.Secret{})
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	// when
+	_, _, err = r.continueSoftDelete(context.Background(), cr)
+
+	// then
+	require.Error(t, err)
+	assert.Equal(t, types.StateDeleting, cr.Status.State)
+	condition := FindStatusCondition(cr.Status.Conditions, ReadyType)
+	require.NotNil(t, condition)
+	assert.Equal(t, string(ServiceResourcesBackupFailed), condition.Reason)
+}
+
+func backedUpInstances(t *testing.T, c client.Client) []string {
+	backup := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: serviceResourcesBackupSecret, Namespace: kymaNamespace}, backup))
+	data, err := decompress(backup.Data[serviceInstancesBackupKey])
+	require.NoError(t, err)
+	objects := make([]*unstructured.Unstructured, 0)
+	require.NoError(t, json.Unmarshal(data, &objects))
+	names := make([]string, 0, len(objects))
+	for _, u := range objects {
+		names = append(names, u.GetName())
+	}
+	return names
+}
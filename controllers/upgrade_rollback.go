@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	lastKnownGoodSecret          = "btp-manager-last-known-good"
+	lastKnownGoodChartVersionKey = "chart-version"
+	lastKnownGoodResourcesKey    = "resources.json.gz"
+)
+
+// lastKnownGood holds the module resources of the last reconciliation in which all of them became ready.
+// It is persisted in the lastKnownGoodSecret, as the resources include the credentials.
+type lastKnownGood struct {
+	chartVersion string
+	resources    []*unstructured.Unstructured
+}
+
+func upgradeRollbackEnabled(cr *v1alpha1.BtpOperator) bool {
+	return cr.Spec.UpgradeRollback != v1alpha1.UpgradeRollbackDisabled
+}
+
+// chartVersionOf returns the chart version the module resources were prepared from
+func chartVersionOf(us []*unstructured.Unstructured) string {
+	if len(us) == 0 {
+		return ""
+	}
+	return us[0].GetLabels()[chartVersionKey]
+}
+
+// getLastKnownGood returns nil if no module resources became ready yet
+func (r *BtpOperatorReconciler) getLastKnownGood(ctx context.Context) (*lastKnownGood, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.config().ChartNamespace, Name: lastKnownGoodSecret}, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	data, err := decompress(secret.Data[lastKnownGoodResourcesKey])
+	if err != nil {
+		return nil, fmt.Errorf("while decompressing %s from %s Secret: %w", lastKnownGoodResourcesKey, lastKnownGoodSecret, err)
+	}
+	objects := make([]map[string]interface{}, 0)
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("while parsing %s from %s Secret: %w", lastKnownGoodResourcesKey, lastKnownGoodSecret, err)
+	}
+
+	resources := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, object := range objects {
+		resources = append(resources, &unstructured.Unstructured{Object: object})
+	}
+	return &lastKnownGood{chartVersion: string(secret.Data[lastKnownGoodChartVersionKey]), resources: resources}, nil
+}
+
+// saveLastKnownGood stores the module resources compressed, so that the rendered CRDs fit into the Secret
+func (r *BtpOperatorReconciler) saveLastKnownGood(ctx context.Context, us []*unstructured.Unstructured) error {
+	objects := make([]map[string]interface{}, 0, len(us))
+	for _, u := range us {
+		objects = append(objects, u.Object)
+	}
+	data, err := json.Marshal(objects)
+	if err != nil {
+		return fmt.Errorf("while marshalling module resources: %w", err)
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return fmt.Errorf("while compressing module resources: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      lastKnownGoodSecret,
+			Namespace: r.config().ChartNamespace,
+		},
+	}
+	_, err = ctrlutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = make(map[string]string)
+		}
+		secret.Labels[managedByLabelKey] = operatorName
+		secret.Data = map[string][]byte{
+			lastKnownGoodChartVersionKey: []byte(chartVersionOf(us)),
+			lastKnownGoodResourcesKey:    compressed,
+		}
+		return nil
+	})
+
+	return err
+}
+
+func isLastKnownGoodSecret(u *unstructured.Unstructured, namespace string) bool {
+	return u.GetKind() == secretKind && u.GetName() == lastKnownGoodSecret && u.GetNamespace() == namespace
+}
+
+// shouldRollbackUpgrade tells whether the module resources of the last-known-good chart version should be applied
+// again after the module resources of a new chart version did not become ready
+func shouldRollbackUpgrade(cr *v1alpha1.BtpOperator, last *lastKnownGood, failed []*unstructured.Unstructured) bool {
+	return upgradeRollbackEnabled(cr) && last != nil && last.chartVersion != chartVersionOf(failed)
+}
+
+// rollbackUpgrade applies the last-known-good module resources again and returns the error with the UpgradeRolledBack reason
+// naming the resources of the new chart version which did not become ready
+func (r *BtpOperatorReconciler) rollbackUpgrade(ctx context.Context, cr *v1alpha1.BtpOperator, last *lastKnownGood, failed []*unstructured.Unstructured, cause error) error {
+	logger := log.FromContext(ctx)
+	failedVersion := chartVersionOf(failed)
+	logger.Info("rolling back the upgrade", "failedChartVersion", failedVersion, "chartVersion", last.chartVersion)
+
+	resources, err := r.rollbackResources(last, failed)
+	if err == nil {
+		r.setResourcesStatus(cr, resources)
+		err = r.applyModuleResources(ctx, cr, resources)
+	}
+	if err != nil {
+		upgradeRollbacksCounter.WithLabelValues(resultFailure).Inc()
+		logger.Error(err, "while rolling back the upgrade")
+		return fmt.Errorf("Upgrade to chart version %s failed: %s. Rollback to chart version %s failed: %w", failedVersion, cause, last.chartVersion, err)
+	}
+
+	upgradeRollbacksCounter.WithLabelValues(resultSuccess).Inc()
+	return NewErrorWithReason(UpgradeRolledBack,
+		fmt.Sprintf("Upgrade to chart version %s rolled back to chart version %s: %s", failedVersion, last.chartVersion, cause))
+}
+
+// rollbackResources returns the last-known-good module resources with the Secrets, the sap-btp-operator ConfigMap
+// and the credentials hash of the Deployment taken from the failed resources. They hold the current credentials
+// and certificates, which do not depend on the chart version, so the rollback never restores outdated credentials.
+func (r *BtpOperatorReconciler) rollbackResources(last *lastKnownGood, failed []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	deploymentName := r.config().DeploymentName
+	isCurrent := func(u *unstructured.Unstructured) bool {
+		return u.GetKind() == secretKind || (u.GetKind() == configMapKind && u.GetName() == btpServiceOperatorConfigMap)
+	}
+
+	current := make(map[string]*unstructured.Unstructured)
+	var hash string
+	for _, u := range failed {
+		if isCurrent(u) {
+			current[resourceKey(u)] = u
+		}
+		if u.GetKind() == deploymentKind && u.GetName() == deploymentName {
+			hash, _, _ = unstructured.NestedString(u.Object, "spec", "template", "metadata", "annotations", credentialsHashAnnotation)
+		}
+	}
+
+	resources := make([]*unstructured.Unstructured, 0, len(last.resources)+len(current))
+	for _, u := range last.resources {
+		if _, found := current[resourceKey(u)]; found || u.GetKind() == secretKind {
+			continue
+		}
+		u = u.DeepCopy()
+		if u.GetKind() == deploymentKind && u.GetName() == deploymentName && hash != "" {
+			if err := r.setCredentialsHash(hash, u); err != nil {
+				return nil, fmt.Errorf("while setting credentials hash: %w", err)
+			}
+		}
+		resources = append(resources, u)
+	}
+	for _, u := range failed {
+		if isCurrent(u) {
+			u = u.DeepCopy()
+			r.addLabels(last.chartVersion, u)
+			resources = append(resources, u)
+		}
+	}
+	return resources, nil
+}
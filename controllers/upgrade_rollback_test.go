@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/btp-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func rollbackTestResources(r *BtpOperatorReconciler, chartVersion, credentialsHash string) []*unstructured.Unstructured {
+	deployment := testUnstructured(appsv1.SchemeGroupVersion.WithKind(deploymentKind), kymaNamespace, deploymentName)
+	_ = r.setCredentialsHash(credentialsHash, deployment)
+	secret := testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: secretKind}, kymaNamespace, "sap-btp-service-operator")
+	secret.Object["data"] = map[string]interface{}{"clientid": credentialsHash}
+	config := testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: configMapKind}, kymaNamespace, btpServiceOperatorConfigMap)
+	config.Object["data"] = map[string]interface{}{"CLUSTER_ID": credentialsHash}
+	service := testUnstructured(schema.GroupVersionKind{Version: "v1", Kind: serviceKind}, kymaNamespace, "sap-btp-operator-webhook-service-"+chartVersion)
+	us := []*unstructured.Unstructured{deployment, secret, config, service}
+	r.addLabels(chartVersion, us...)
+	return us
+}
+
+func TestLastKnownGood(t *testing.T) {
+	// given
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	r := NewBtpOperatorReconciler(c, clientgoscheme.Scheme)
+	us := rollbackTestResources(r, "v0.2.3", "old")
+
+	// when
+	last, err := r.getLastKnownGood(context.Background())
+
+	// then
+	require.NoError(t, err)
+	assert.Nil(t, last)
+
+	// when
+	require.NoError(t, r.saveLastKnownGood(context.Background(), us))
+	last, err = r.getLastKnownGood(context.Background())
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "v0.2.3", last.chartVersion)
+	assert.Equal(t, us, last.resources)
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: kymaNamespace, Name: lastKnownGoodSecret}, secret))
+	assert.Equal(t, operatorName, secret.Labels[managedByLabelKey])
+	assert.NotContains(t, string(secret.Data[lastKnownGoodResourcesKey]), "sap-btp-service-operator", "resources should be compressed")
+}
+
+func TestRollbackResources(t *testing.T) {
+	// given
+	r := NewBtpOperatorReconciler(nil, clientgoscheme.Scheme)
+	last := &lastKnownGood{chartVersion: "v0.2.3", resources: rollbackTestResources(r, "v0.2.3", "old")}
+	failed := rollbackTestResources(r, "v0.2.4", "new")
+
+	// when
+	resources, err := r.rollbackResources(last, failed)
+
+	// then
+	require.NoError(t, err)
+	byKind := make(map[string]*unstructured.Unstructured)
+	for _, u := range resources {
+		byKind[u.GetKind()] = u
+		assert.Equal(t, "v0.2.3", u.GetLabels()[chartVersionKey], u.GetName())
+	}
+	assert.Len(t, resources, 4)
+	assert.Equal(t, "sap-btp-operator-webhook-service-v0.2.3", byKind[serviceKind].GetName())
+	assert.Equal(t, "new", byKind[secretKind].Object["data"].(map[string]interface{})["clientid"], "current credentials should be kept")
+	assert.Equal(t, "new", byKind[configMapKind].Object["data"].(map[string]interface{})["CLUSTER_ID"])
+	hash, _, _ := unstructured.NestedString(byKind[deploymentKind].Object, "spec", "template", "metadata", "annotations", credentialsHashAnnotation)
+	assert.Equal(t, "new", hash)
+	assert.Equal(t, "v0.2.4", failed[0].GetLabels()[chartVersionKey], "failed resources should not be changed")
+}
+
+func TestShouldRollbackUpgrade(t *testing.T) {
+	r := NewBtpOperatorReconciler(nil, clientgoscheme.Scheme)
+	last := &lastKnownGood{chartVersion: "v0.2.3", resources: rollbackTestResources(r, "v0.2.3", "old")}
+	upgraded := rollbackTestResources(r, "v0.2.4", "new")
+	enabled := &v1alpha1.BtpOperator{}
+	disabled := &v1alpha1.BtpOperator{Spec: v1alpha1.BtpOperatorSpec{UpgradeRollback: v1alpha1.UpgradeRollbackDisabled}}
+
+	assert.True(t, shouldRollbackUpgrade(enabled, last, upgraded))
+	assert.False(t, shouldRollbackUpgrade(disabled, last, upgraded))
+	assert.False(t, shouldRollbackUpgrade(enabled, last, last.resources), "the same chart version is not an upgrade")
+	assert.False(t, shouldRollbackUpgrade(enabled, nil, upgraded), "nothing to roll back to")
+}
@@ -0,0 +1,105 @@
+package renderer
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// RenderChart renders templates of the chart stored in chartPath with the given values merged over the chart defaults
+// and returns every non-empty document as a separate manifest. Manifests are ordered by template file name.
+func RenderChart(chartPath, releaseName, namespace string, values map[string]interface{}) ([]string, error) {
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("while loading chart from %s: %w", chartPath, err)
+	}
+
+	options := chartutil.ReleaseOptions{
+		Name:      releaseName,
+		Namespace: namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, values, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, fmt.Errorf("while preparing values to render: %w", err)
+	}
+
+	rendered, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, fmt.Errorf("while rendering chart templates: %w", err)
+	}
+
+	templates := make([]string, 0, len(rendered))
+	for name := range rendered {
+		if isManifestTemplate(name) {
+			templates = append(templates, name)
+		}
+	}
+	sort.Strings(templates)
+
+	manifests := make([]string, 0)
+	for _, name := range templates {
+		for _, document := range documentSeparator.Split(rendered[name], -1) {
+			if strings.TrimSpace(document) == "" {
+				continue
+			}
+			manifests = append(manifests, document)
+		}
+	}
+
+	return manifests, nil
+}
+
+func isManifestTemplate(name string) bool {
+	base := filepath.Base(name)
+	if strings.HasPrefix(base, "_") {
+		return false
+	}
+	ext := filepath.Ext(base)
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// ReadValuesFile reads Helm values from a YAML file
+func ReadValuesFile(path string) (map[string]interface{}, error) {
+	values, err := chartutil.ReadValuesFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return values.AsMap(), nil
+}
+
+// MergeValues deep merges the given values maps into a new map. Values from later maps take precedence.
+func MergeValues(values ...map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, v := range values {
+		mergeInto(result, v)
+	}
+	return result
+}
+
+func mergeInto(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcTable, srcIsTable := v.(map[string]interface{})
+		dstTable, dstIsTable := dst[k].(map[string]interface{})
+		if srcIsTable && dstIsTable {
+			mergeInto(dstTable, srcTable)
+			continue
+		}
+		if srcIsTable {
+			copied := make(map[string]interface{}, len(srcTable))
+			mergeInto(copied, srcTable)
+			dst[k] = copied
+			continue
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,66 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chartPath     = "../../module-chart/chart"
+	overridesPath = "../../module-chart/overrides.yaml"
+)
+
+func TestRenderChart(t *testing.T) {
+	overrides, err := ReadValuesFile(overridesPath)
+	require.NoError(t, err)
+	values := MergeValues(overrides, map[string]interface{}{
+		"cluster": map[string]interface{}{"id": "test-cluster-id"},
+		"manager": map[string]interface{}{"replica_count": 3},
+	})
+
+	manifests, err := RenderChart(chartPath, "sap-btp-operator", "test-namespace", values)
+	require.NoError(t, err)
+	require.NotEmpty(t, manifests)
+
+	var deployment, configMap string
+	for _, m := range manifests {
+		assert.NotEmpty(t, strings.TrimSpace(m))
+		if strings.Contains(m, "kind: Deployment") {
+			deployment = m
+		}
+		if strings.Contains(m, "kind: ConfigMap") {
+			configMap = m
+		}
+	}
+	assert.Contains(t, deployment, "namespace: test-namespace")
+	assert.Contains(t, deployment, "replicas: 3")
+	assert.Contains(t, configMap, "CLUSTER_ID: test-cluster-id")
+}
+
+func TestRenderChartWithMissingChart(t *testing.T) {
+	_, err := RenderChart("./not-existing-chart", "sap-btp-operator", "test-namespace", nil)
+	assert.Error(t, err)
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]interface{}{
+		"manager": map[string]interface{}{"replica_count": 1, "cpu_limit": "250m"},
+		"cluster": map[string]interface{}{"id": "base"},
+	}
+	overrides := map[string]interface{}{
+		"manager": map[string]interface{}{"replica_count": 2},
+		"extra":   "value",
+	}
+
+	merged := MergeValues(base, overrides)
+
+	assert.Equal(t, map[string]interface{}{
+		"manager": map[string]interface{}{"replica_count": 2, "cpu_limit": "250m"},
+		"cluster": map[string]interface{}{"id": "base"},
+		"extra":   "value",
+	}, merged)
+	assert.Equal(t, 1, base["manager"].(map[string]interface{})["replica_count"], "input values must not be modified")
+}
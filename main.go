@@ -30,6 +30,7 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -60,18 +61,30 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	flag.StringVar(&controllers.ChartNamespace, "chart-namespace", controllers.ChartNamespace, "Namespace to install chart resources.")
-	flag.StringVar(&controllers.SecretName, "secret-name", controllers.SecretName, "Secret name with input values for sap-btp-operator chart templating.")
-	flag.StringVar(&controllers.ConfigName, "config-name", controllers.ConfigName, "ConfigMap name with configuration knobs for the btp-manager internals.")
-	flag.StringVar(&controllers.DeploymentName, "deployment-name", controllers.DeploymentName, "Name of the deployment of sap-btp-operator for deprovisioning.")
-	flag.StringVar(&controllers.ChartPath, "chart-path", controllers.ChartPath, "Path to the root directory inside the chart.")
-	flag.StringVar(&controllers.ResourcesPath, "resources-path", controllers.ResourcesPath, "Path to the directory with module resources to apply/delete.")
-	flag.DurationVar(&controllers.ProcessingStateRequeueInterval, "processing-state-requeue-interval", controllers.ProcessingStateRequeueInterval, `Requeue interval for state "processing".`)
-	flag.DurationVar(&controllers.ReadyStateRequeueInterval, "ready-state-requeue-interval", controllers.ReadyStateRequeueInterval, `Requeue interval for state "ready".`)
-	flag.DurationVar(&controllers.ReadyTimeout, "ready-timeout", controllers.ReadyTimeout, "Helm chart timeout.")
-	flag.DurationVar(&controllers.ReadyCheckInterval, "ready-check-interval", controllers.ReadyCheckInterval, "Ready check retry interval.")
-	flag.DurationVar(&controllers.HardDeleteCheckInterval, "hard-delete-check-interval", controllers.HardDeleteCheckInterval, "Hard delete retry interval.")
-	flag.DurationVar(&controllers.HardDeleteTimeout, "hard-delete-timeout", controllers.HardDeleteTimeout, "Hard delete timeout.")
+	cfg := controllers.DefaultConfig()
+	flag.StringVar(&cfg.ChartNamespace, "chart-namespace", cfg.ChartNamespace, "Namespace to install chart resources.")
+	flag.StringVar(&cfg.SecretName, "secret-name", cfg.SecretName, "Secret name with input values for sap-btp-operator chart templating.")
+	flag.StringVar(&cfg.ConfigName, "config-name", cfg.ConfigName, "ConfigMap name with configuration knobs for the btp-manager internals.")
+	flag.StringVar(&cfg.DeploymentName, "deployment-name", cfg.DeploymentName, "Name of the deployment of sap-btp-operator for deprovisioning.")
+	flag.StringVar(&cfg.ChartPath, "chart-path", cfg.ChartPath, "Path to the root directory inside the chart.")
+	flag.StringVar(&cfg.ResourcesPath, "resources-path", cfg.ResourcesPath, "Path to the directory with module resources to apply/delete.")
+	flag.StringVar(&cfg.ChartOverridesPath, "chart-overrides-path", cfg.ChartOverridesPath, "Path to the file with values overrides for the chart.")
+	flag.BoolVar(&cfg.RenderChart, "render-chart", cfg.RenderChart, "Render the chart in-process. If disabled, pre-rendered module resources are applied.")
+	flag.DurationVar(&cfg.ProcessingStateRequeueInterval, "processing-state-requeue-interval", cfg.ProcessingStateRequeueInterval, `Requeue interval for state "processing".`)
+	flag.DurationVar(&cfg.ReadyStateRequeueInterval, "ready-state-requeue-interval", cfg.ReadyStateRequeueInterval, `Requeue interval for state "ready".`)
+	flag.DurationVar(&cfg.ReadyTimeout, "ready-timeout", cfg.ReadyTimeout, "Helm chart timeout.")
+	flag.DurationVar(&cfg.ReadyCheckInterval, "ready-check-interval", cfg.ReadyCheckInterval, "Ready check retry interval.")
+	flag.DurationVar(&cfg.HardDeleteCheckInterval, "hard-delete-check-interval", cfg.HardDeleteCheckInterval, "Hard delete retry interval.")
+	flag.DurationVar(&cfg.HardDeleteTimeout, "hard-delete-timeout", cfg.HardDeleteTimeout, "Hard delete timeout.")
+	flag.BoolVar(&cfg.CredentialsCheck, "credentials-check", cfg.CredentialsCheck, "Check the credentials from the required Secret against the token endpoint and the Service Manager.")
+	flag.DurationVar(&cfg.CredentialsCheckTimeout, "credentials-check-timeout", cfg.CredentialsCheckTimeout, "Timeout of the credentials check.")
+	flag.DurationVar(&cfg.CertificateExpiryWarningPeriod, "certificate-expiry-warning-period", cfg.CertificateExpiryWarningPeriod, "Period before the expiry of the credentials certificate in which a warning condition is set.")
+	flag.DurationVar(&cfg.DeletionBlockedRequeueInterval, "deletion-blocked-requeue-interval", cfg.DeletionBlockedRequeueInterval, "Requeue interval for deletion blocked by the deletion protection.")
+	flag.BoolVar(&cfg.ApplyDiff, "apply-diff", cfg.ApplyDiff, "Report the changes and the field manager conflicts of module resources found with a server-side apply dry run before applying them.")
+	flag.Func("protected-field-managers", "Comma-separated field managers from which the ownership of conflicting fields of module resources is not forced.", func(value string) (err error) {
+		cfg.ProtectedFieldManagers, err = controllers.ParseFieldManagers(value)
+		return err
+	})
 	opts := zap.Options{
 		Development: true,
 	}
@@ -80,6 +93,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := cfg.Validate(); err != nil {
+		setupLog.Error(err, "invalid configuration")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -87,6 +105,7 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "ec023d38.kyma-project.io",
+		NewCache:               cache.BuilderWithOptions(cache.Options{SelectorsByObject: controllers.ManagedResourcesCacheSelectors()}),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -105,11 +124,18 @@ func main() {
 	}
 
 	reconciler := controllers.NewBtpOperatorReconciler(mgr.GetClient(), scheme)
+	reconciler.SetConfig(cfg)
 
 	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BtpOperator")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&v1alpha1.BtpOperator{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BtpOperator")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
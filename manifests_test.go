@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+func TestDefaultManifestsDeployWebhook(t *testing.T) {
+	// given
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+
+	// when
+	resMap, err := kustomizer.Run(filesys.MakeFsOnDisk(), "config/default")
+
+	// then
+	require.NoError(t, err)
+	resources := make([]*unstructured.Unstructured, 0, resMap.Size())
+	for _, res := range resMap.Resources() {
+		data, err := res.MarshalJSON()
+		require.NoError(t, err)
+		u := &unstructured.Unstructured{}
+		require.NoError(t, u.UnmarshalJSON(data))
+		resources = append(resources, u)
+	}
+
+	webhookConfig := findResource(t, resources, "ValidatingWebhookConfiguration", "btp-manager-validating-webhook-configuration")
+	assert.Equal(t, "kyma-system/btp-manager-serving-cert", webhookConfig.GetAnnotations()["cert-manager.io/inject-ca-from"])
+	webhooks, _, _ := unstructured.NestedSlice(webhookConfig.Object, "webhooks")
+	require.Len(t, webhooks, 1)
+	webhook := webhooks[0].(map[string]interface{})
+	service, _, _ := unstructured.NestedStringMap(webhook, "clientConfig", "service")
+	assert.Equal(t, map[string]string{
+		"name":      "btp-manager-webhook-service",
+		"namespace": "kyma-system",
+		"path":      "/validate-operator-kyma-project-io-v1alpha1-btpoperator",
+	}, service)
+	rules, _, _ := unstructured.NestedSlice(webhook, "rules")
+	require.Len(t, rules, 1)
+	resourceNames, _, _ := unstructured.NestedStringSlice(rules[0].(map[string]interface{}), "resources")
+	assert.Equal(t, []string{"btpoperators"}, resourceNames)
+
+	findResource(t, resources, "Service", "btp-manager-webhook-service")
+	certificate := findResource(t, resources, "Certificate", "btp-manager-serving-cert")
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Equal(t, []string{
+		"btp-manager-webhook-service.kyma-system.svc",
+		"btp-manager-webhook-service.kyma-system.svc.cluster.local",
+	}, dnsNames)
+
+	deployment := findResource(t, resources, "Deployment", "btp-manager-controller-manager")
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	var env []interface{}
+	for _, container := range containers {
+		if name, _, _ := unstructured.NestedString(container.(map[string]interface{}), "name"); name == "manager" {
+			env, _, _ = unstructured.NestedSlice(container.(map[string]interface{}), "env")
+		}
+	}
+	assert.Contains(t, env, map[string]interface{}{"name": "ENABLE_WEBHOOKS", "value": "true"})
+}
+
+func findResource(t *testing.T, resources []*unstructured.Unstructured, kind, name string) *unstructured.Unstructured {
+	for _, res := range resources {
+		if res.GetKind() == kind && res.GetName() == name {
+			return res
+		}
+	}
+	require.Failf(t, "resource not found", "%s %s is not rendered", kind, name)
+	return nil
+}